@@ -0,0 +1,15 @@
+// Command depcheck reports imports that DEPENDENCY.md does not allow.
+//
+// It can run standalone or through go vet:
+//
+//	go vet -vettool=$(which depcheck) ./...
+package main
+
+import (
+	"github.com/handlename/go-package-dependency/depcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(depcheck.Analyzer)
+}
@@ -0,0 +1,178 @@
+// Package depcheck exposes the rules of DEPENDENCY.md as a go/analysis
+// analyzer, for use with go vet, golangci-lint and other drivers.
+package depcheck
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/handlename/go-package-dependency/dependency"
+	"golang.org/x/tools/go/analysis"
+)
+
+// DependencyFileName is the file the analyzer looks for
+const DependencyFileName = "DEPENDENCY.md"
+
+const doc = `report imports that DEPENDENCY.md does not allow
+
+The depcheck analyzer finds DEPENDENCY.md by walking up from the directory
+of each package, and reports imports that break its layer, shared package,
+external import and test rules, as well as uses of banned symbols.
+Packages that DEPENDENCY.md does not list are not checked.
+
+A //depcheck:ignore reason=... comment on an import, or before the package
+clause, suppresses its violations. Suppressions without a reason, past
+their until=YYYY-MM-DD date or suppressing nothing are reported.
+
+Rules whose severity the Severities section of DEPENDENCY.md lowers to
+warning or info are not reported, since every diagnostic fails go vet.`
+
+// Analyzer finds DEPENDENCY.md by walking up from each package directory
+var Analyzer = NewAnalyzer("")
+
+// NewAnalyzer returns an analyzer that reads the rules from dependencyFile,
+// or finds DEPENDENCY.md by walking up from each package directory when
+// dependencyFile is empty
+func NewAnalyzer(dependencyFile string) *analysis.Analyzer {
+	c := &checker{
+		dependencyFile: dependencyFile,
+		loaded:         make(map[string]*rules),
+	}
+
+	analyzer := &analysis.Analyzer{
+		Name: "depcheck",
+		Doc:  doc,
+		URL:  "https://github.com/handlename/go-package-dependency",
+		Run:  c.run,
+	}
+	analyzer.Flags.StringVar(&c.dependencyFile, "dependency-file", dependencyFile, "path to DEPENDENCY.md, found by walking up from each package directory when empty")
+
+	return analyzer
+}
+
+// rules is a parsed DEPENDENCY.md with the module it belongs to
+type rules struct {
+	baseDir    string
+	config     *dependency.DependencyConfig
+	moduleName dependency.ModuleName
+}
+
+type checker struct {
+	dependencyFile string
+
+	mu     sync.Mutex
+	loaded map[string]*rules
+}
+
+func (c *checker) run(pass *analysis.Pass) (any, error) {
+	if len(pass.Files) == 0 {
+		return nil, nil
+	}
+
+	packageDir := filepath.Dir(pass.Fset.Position(pass.Files[0].Package).Filename)
+
+	dependencyFile := c.dependencyFile
+	if dependencyFile == "" {
+		found, ok := FindDependencyFile(packageDir)
+		if !ok {
+			return nil, nil
+		}
+		dependencyFile = found
+	}
+
+	r, err := c.load(dependencyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	relativeDir, err := filepath.Rel(r.baseDir, packageDir)
+	if err != nil || relativeDir == ".." || strings.HasPrefix(relativeDir, ".."+string(filepath.Separator)) {
+		return nil, nil
+	}
+
+	pkg, ok := r.config.FindPackage(dependency.LayerPath(filepath.ToSlash(relativeDir)))
+	if !ok || pkg.Path.String() != filepath.ToSlash(relativeDir) {
+		return nil, nil
+	}
+
+	analyzer := &dependency.Analyzer{Info: pass.TypesInfo}
+	for _, file := range pass.Files {
+		name := filepath.Base(pass.Fset.Position(file.Package).Filename)
+		if name == dependency.GeneratedFileName || name == dependency.GeneratedTestFileName {
+			continue
+		}
+
+		for _, violation := range analyzer.AnalyzeAST(pass.Fset, file, pkg, r.config, r.moduleName) {
+			// Diagnostics fail go vet, so only errors are reported
+			if !r.config.ViolationSeverity(violation).AtLeast(dependency.SeverityError) {
+				continue
+			}
+			pass.Report(analysis.Diagnostic{
+				Pos:     violation.Pos,
+				Message: violation.Message(),
+			})
+		}
+	}
+
+	return nil, nil
+}
+
+// load parses DEPENDENCY.md and the go.mod next to it once per file
+func (c *checker) load(dependencyFile string) (*rules, error) {
+	dependencyFile, err := filepath.Abs(dependencyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r, ok := c.loaded[dependencyFile]; ok {
+		return r, nil
+	}
+
+	parser := dependency.NewParser()
+	config, err := parser.ParseDependencyFile(dependencyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	baseDir := filepath.Dir(dependencyFile)
+	moduleName, err := parser.GetModuleName(filepath.Join(baseDir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &rules{baseDir: baseDir, config: config, moduleName: moduleName}
+	c.loaded[dependencyFile] = r
+	return r, nil
+}
+
+// FindDependencyFile walks up from dir to the filesystem root and returns
+// the first DEPENDENCY.md found
+func FindDependencyFile(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		candidate := filepath.Join(dir, DependencyFileName)
+		info, err := os.Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, true
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", false
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,36 @@
+package depcheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, NewAnalyzer(""), "./...")
+}
+
+func TestAnalyzer_DependencyFileFlag(t *testing.T) {
+	testdata := analysistest.TestData()
+	analysistest.Run(t, testdata, NewAnalyzer(filepath.Join(testdata, DependencyFileName)), "./domain/entity")
+}
+
+func TestFindDependencyFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	nested := filepath.Join(tmpDir, "domain", "entity")
+	assert.NoError(t, os.MkdirAll(nested, 0755))
+
+	_, found := FindDependencyFile(nested)
+	assert.False(t, found)
+
+	dependencyFile := filepath.Join(tmpDir, DependencyFileName)
+	assert.NoError(t, os.WriteFile(dependencyFile, []byte("# Dependencies\n"), 0644))
+
+	path, found := FindDependencyFile(nested)
+	assert.True(t, found)
+	assert.Equal(t, dependencyFile, path)
+}
@@ -0,0 +1,36 @@
+package depcheck
+
+import (
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+)
+
+func init() {
+	register.Plugin("depcheck", NewPlugin)
+}
+
+// PluginSettings are the settings of the golangci-lint module plugin
+type PluginSettings struct {
+	DependencyFile string `json:"dependency-file"`
+}
+
+type plugin struct {
+	settings PluginSettings
+}
+
+// NewPlugin is the entry point for golangci-lint module plugins
+func NewPlugin(settings any) (register.LinterPlugin, error) {
+	s, err := register.DecodeSettings[PluginSettings](settings)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin{settings: s}, nil
+}
+
+func (p *plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	return []*analysis.Analyzer{NewAnalyzer(p.settings.DependencyFile)}, nil
+}
+
+func (p *plugin) GetLoadMode() string {
+	return register.LoadModeTypesInfo
+}
@@ -0,0 +1,9 @@
+package usecase
+
+import (
+	"net/http"
+	"time"
+)
+
+var _ = http.MethodGet
+var _ = time.Now
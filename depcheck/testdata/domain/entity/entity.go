@@ -0,0 +1,17 @@
+package entity
+
+import (
+	_ "net/http" // want `domain/entity must not import net/http \(1\. Domain layer: forbid net/http\)`
+	"time"
+
+	_ "example.com/app/app/usecase" // want `domain/entity must not import example.com/app/app/usecase`
+)
+
+func Now() time.Time {
+	return time.Now() // want `domain/entity must not use time.Now`
+}
+
+func Shadowed() {
+	time := struct{ Now func() }{Now: func() {}}
+	time.Now()
+}
@@ -0,0 +1,6 @@
+package entity
+
+import (
+	//depcheck:ignore reason="kept until the HTTP client moves to infra"
+	_ "net/http"
+)
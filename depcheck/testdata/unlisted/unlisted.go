@@ -0,0 +1,3 @@
+package unlisted
+
+import _ "example.com/app/domain/entity"
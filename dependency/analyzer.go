@@ -0,0 +1,433 @@
+package dependency
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var majorVersionRegex = regexp.MustCompile(`^v[0-9]+$`)
+
+// sharedImportReason is the Reason of a shared package importing outside the standard library
+const sharedImportReason = "shared packages may only import the standard library"
+
+// ImportViolation describes an import that DEPENDENCY.md does not allow
+type ImportViolation struct {
+	File       string
+	Line       int
+	Column     int
+	Importer   LayerPath // Listed package that contains the importing file
+	Imported   LayerPath // Listed package that contains the imported path, empty if unlisted
+	ImportPath string    // Import path as written in the source
+	Symbol     string    // Banned symbol used, empty for import violations
+	Reason     string    // Rule that forbids the import, empty for layer violations
+	FileKind   FileKind
+	Pos        token.Pos // Position in the file set the file was parsed with
+	Severity   Severity  // Set by the layer, scope or rule of DEPENDENCY.md, empty for the rule's
+
+	// Suppression is set for a //depcheck:ignore comment that is invalid,
+	// expired or unused, with Reason describing the problem
+	Suppression SuppressionProblem
+}
+
+// Message describes the violation without its location
+func (v ImportViolation) Message() string {
+	if v.Suppression != SuppressionNone {
+		return v.Reason
+	}
+
+	message := fmt.Sprintf("%s must not import %s", v.Importer, v.ImportPath)
+	if v.Symbol != "" {
+		message = fmt.Sprintf("%s must not use %s", v.Importer, v.Symbol)
+	}
+	if v.Reason != "" {
+		message += fmt.Sprintf(" (%s)", v.Reason)
+	}
+	return message
+}
+
+func (v ImportViolation) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", v.File, v.Line, v.Column, v.Message())
+}
+
+// RuleID returns the ID of the rule the violation breaks in reports
+func (v ImportViolation) RuleID() string {
+	switch {
+	case v.Suppression == SuppressionInvalid:
+		return RuleInvalidSuppression
+	case v.Suppression == SuppressionExpired:
+		return RuleExpiredSuppression
+	case v.Suppression == SuppressionUnused:
+		return RuleUnusedSuppression
+	case v.Symbol != "":
+		return RuleBannedSymbol
+	case v.Reason == sharedImportReason:
+		return RuleSharedImport
+	case v.Reason != "":
+		return RuleExternalImport
+	default:
+		return RuleLayerViolation
+	}
+}
+
+// FileKind tells production files apart from test files
+type FileKind int
+
+const (
+	FileKindProduction   FileKind = iota
+	FileKindInternalTest          // _test.go file in the package itself
+	FileKindExternalTest          // _test.go file in the package_test package
+)
+
+func (k FileKind) String() string {
+	switch k {
+	case FileKindInternalTest:
+		return "internal test"
+	case FileKindExternalTest:
+		return "external test"
+	default:
+		return "production"
+	}
+}
+
+// ClassifyFile returns the kind of a Go file from its name and package clause
+func ClassifyFile(fileName string, packageName string) FileKind {
+	if !strings.HasSuffix(fileName, "_test.go") {
+		return FileKindProduction
+	}
+	if strings.HasSuffix(packageName, "_test") {
+		return FileKindExternalTest
+	}
+	return FileKindInternalTest
+}
+
+type Analyzer struct {
+	// Info resolves identifiers with type information when set. Otherwise
+	// the parser's object resolution tells imports apart from local names.
+	Info *types.Info
+	// Workers bounds how many files AnalyzeImports parses at once.
+	// Zero means GOMAXPROCS.
+	Workers int
+	// Now is the time //depcheck:ignore until dates are compared with,
+	// the current time when zero
+	Now time.Time
+}
+
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{}
+}
+
+// AnalyzeImports checks the imports of every listed package against the config
+func (a *Analyzer) AnalyzeImports(baseDir string, config *DependencyConfig) ([]ImportViolation, error) {
+	return a.AnalyzeImportsContext(context.Background(), baseDir, config)
+}
+
+// AnalyzeImportsContext is AnalyzeImports that stops parsing files once ctx is done
+func (a *Analyzer) AnalyzeImportsContext(ctx context.Context, baseDir string, config *DependencyConfig) ([]ImportViolation, error) {
+	parser := NewParser()
+	moduleName, err := parser.GetModuleName(filepath.Join(baseDir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+
+	config = config.indexed()
+
+	files, err := listSourceFiles(baseDir, config)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([][]ImportViolation, len(files))
+	errs := forEach(ctx, a.Workers, len(files), true, func(i int) error {
+		violations, err := a.AnalyzeFile(files[i].path, files[i].pkg, config, moduleName)
+		found[i] = violations
+		return err
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	var violations []ImportViolation
+	for _, fileViolations := range found {
+		violations = append(violations, fileViolations...)
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].File != violations[j].File {
+			return violations[i].File < violations[j].File
+		}
+		if violations[i].Line != violations[j].Line {
+			return violations[i].Line < violations[j].Line
+		}
+		return violations[i].Column < violations[j].Column
+	})
+
+	return violations, nil
+}
+
+// sourceFile is a Go file of a listed package
+type sourceFile struct {
+	path string
+	pkg  Package
+}
+
+// listSourceFiles returns the Go files of every listed package, except
+// generated ones. Generated files are recognised by their default names or,
+// when generated with another file name, by the generated code header.
+// Packages without a directory are skipped.
+func listSourceFiles(baseDir string, config *DependencyConfig) ([]sourceFile, error) {
+	var files []sourceFile
+	for _, pkg := range config.GetAllPackages() {
+		packageDir := filepath.Join(baseDir, pkg.Path.String())
+
+		entries, err := os.ReadDir(packageDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || entry.Name() == GeneratedFileName || entry.Name() == GeneratedTestFileName {
+				continue
+			}
+			filePath := filepath.Join(packageDir, entry.Name())
+			generated, err := hasGeneratedHeader(filePath)
+			if err != nil {
+				return nil, err
+			}
+			if !generated {
+				files = append(files, sourceFile{path: filePath, pkg: pkg})
+			}
+		}
+	}
+	return files, nil
+}
+
+// hasGeneratedHeader tells whether the file starts with the generated code header
+func hasGeneratedHeader(filePath string) (bool, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	head := make([]byte, len(generatedHeader))
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return false, err
+	}
+	return isGenerated(head[:n]), nil
+}
+
+// AnalyzeFile checks the imports and banned symbols of a single file belonging to pkg
+func (a *Analyzer) AnalyzeFile(filePath string, pkg Package, config *DependencyConfig, moduleName ModuleName) ([]ImportViolation, error) {
+	// Only read past the imports when there are symbols to look for
+	bannedSymbols := config.GetBannedSymbols(pkg)
+	mode := parser.ImportsOnly | parser.ParseComments
+	if len(bannedSymbols) > 0 {
+		mode = parser.ParseComments
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, nil, mode)
+	if err != nil {
+		return nil, SourceParseError{Path: filePath, Err: err}
+	}
+
+	return a.AnalyzeAST(fset, file, pkg, config, moduleName), nil
+}
+
+// AnalyzeAST checks the imports and banned symbols of an already parsed file belonging to pkg
+func (a *Analyzer) AnalyzeAST(fset *token.FileSet, file *ast.File, pkg Package, config *DependencyConfig, moduleName ModuleName) []ImportViolation {
+	filePath := fset.Position(file.Package).Filename
+	bannedSymbols := config.GetBannedSymbols(pkg)
+
+	// Test files follow the test mode, and relaxed tests may import anything in the module
+	kind := ClassifyFile(filepath.Base(filePath), file.Name.Name)
+	isAllowed := config.IsDependencyAllowed
+	if kind != FileKindProduction {
+		isAllowed = config.IsTestDependencyAllowed
+	}
+	relaxed := kind != FileKindProduction && config.TestMode == TestModeRelaxed
+
+	// Shared packages may only import other shared packages and the standard library
+	shared := config.IsShared(pkg.Path) && !relaxed
+
+	var violations []ImportViolation
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		var (
+			imported Package
+			reason   string
+			severity Severity
+		)
+		if importedPath, ok := moduleName.RelativePath(importPath); ok {
+			found, ok := config.FindPackage(importedPath)
+			if ok && isAllowed(pkg, found) {
+				continue
+			}
+			if !ok && !shared {
+				continue
+			}
+			imported = found
+			if layer := config.FindLayer(pkg.Path); layer != nil {
+				severity = layer.Severity
+			}
+		} else if shared && !IsStandardLibrary(importPath) {
+			reason = sharedImportReason
+		} else if allowed, rule := config.CheckExternalImport(pkg, importPath); !allowed {
+			reason = rule.String()
+			severity = rule.Severity
+		} else {
+			continue
+		}
+
+		position := fset.Position(spec.Pos())
+		violations = append(violations, ImportViolation{
+			File:       filePath,
+			Line:       position.Line,
+			Column:     position.Column,
+			Importer:   pkg.Path,
+			Imported:   imported.Path,
+			ImportPath: importPath,
+			Reason:     reason,
+			FileKind:   kind,
+			Pos:        spec.Pos(),
+			Severity:   severity,
+		})
+	}
+
+	violations = append(violations, a.findBannedSymbols(fset, file, filePath, pkg, kind, bannedSymbols)...)
+
+	now := a.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	return applySuppressions(fset, file, filePath, pkg, kind, violations, now)
+}
+
+// findBannedSymbols reports selector expressions like time.Now whose left
+// side names an import of the file. It relies on the parser's object
+// resolution instead of go/types, so identifiers declared in the file that
+// shadow an import are not mistaken for it.
+func (a *Analyzer) findBannedSymbols(fset *token.FileSet, file *ast.File, filePath string, pkg Package, kind FileKind, rules []BannedSymbolRule) []ImportViolation {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	// Map local import names to import paths
+	importNames := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := GuessImportName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." {
+			continue
+		}
+		importNames[name] = importPath
+	}
+
+	var violations []ImportViolation
+	ast.Inspect(file, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		ident, ok := selector.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		importPath, ok := a.resolveImport(ident, importNames)
+		if !ok {
+			return true
+		}
+
+		for _, rule := range rules {
+			if rule.Symbol.ImportPath != importPath || rule.Symbol.Name != selector.Sel.Name {
+				continue
+			}
+
+			position := fset.Position(selector.Pos())
+			violations = append(violations, ImportViolation{
+				File:       filePath,
+				Line:       position.Line,
+				Column:     position.Column,
+				Importer:   pkg.Path,
+				ImportPath: importPath,
+				Symbol:     rule.Symbol.String(),
+				Reason:     rule.String(),
+				FileKind:   kind,
+				Pos:        selector.Pos(),
+				Severity:   rule.Severity,
+			})
+			break
+		}
+		return true
+	})
+
+	return violations
+}
+
+// resolveImport returns the import path an identifier refers to, if any
+func (a *Analyzer) resolveImport(ident *ast.Ident, importNames map[string]string) (string, bool) {
+	if a.Info != nil {
+		pkgName, ok := a.Info.Uses[ident].(*types.PkgName)
+		if !ok {
+			return "", false
+		}
+		return pkgName.Imported().Path(), true
+	}
+
+	if ident.Obj != nil {
+		return "", false
+	}
+	importPath, ok := importNames[ident.Name]
+	return importPath, ok
+}
+
+// GuessImportName returns the name an import is referred to by when it has
+// no explicit name. Without type information this follows the conventions:
+// the last path element, skipping a major version suffix like "v2", with a
+// "go-" prefix and any ".suffix" removed.
+func GuessImportName(importPath string) string {
+	elements := strings.Split(importPath, "/")
+	name := elements[len(elements)-1]
+	if len(elements) > 1 && majorVersionRegex.MatchString(name) {
+		name = elements[len(elements)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name, _, _ = strings.Cut(name, ".")
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// IsStandardLibrary reports whether the import path belongs to the standard library,
+// whose first path element never contains a dot
+func IsStandardLibrary(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
@@ -0,0 +1,373 @@
+package dependency
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestAnalyzeImports(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), "module github.com/test/project\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(tmpDir, "domain/domain.go"), `package domain
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "ports/ports.go"), `package ports
+
+import (
+	_ "github.com/test/project/domain"
+	_ "github.com/test/project/adapters/http"
+)
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "adapters/http/http.go"), `package http
+
+import (
+	_ "fmt"
+	_ "github.com/test/project/domain"
+	_ "github.com/test/project/ports"
+)
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "adapters/postgres/postgres.go"), `package postgres
+
+import (
+	_ "github.com/test/project/adapters/http/internal"
+	_ "github.com/test/project/adapters/postgres/schema"
+	_ "github.com/test/project/unlisted"
+)
+`)
+	// Generated files are never analyzed
+	writeTestFile(t, filepath.Join(tmpDir, "domain", GeneratedFileName), `package domain
+
+import _ "github.com/test/project/ports"
+`)
+
+	config := &DependencyConfig{}
+	require.NoError(t, config.ApplyStyle(StyleHexagonal))
+	config.Layers[0].Packages = []Package{{Path: LayerPath("domain")}}
+	config.Layers[1].Packages = []Package{{Path: LayerPath("ports")}}
+	config.Layers[2].Packages = []Package{
+		{Path: LayerPath("adapters/http")},
+		{Path: LayerPath("adapters/postgres")},
+	}
+
+	analyzer := NewAnalyzer()
+	violations, err := analyzer.AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+
+	require.Len(t, violations, 2)
+
+	assert.Equal(t, filepath.Join(tmpDir, "adapters/postgres/postgres.go"), violations[0].File)
+	assert.Equal(t, 4, violations[0].Line)
+	assert.Equal(t, LayerPath("adapters/postgres"), violations[0].Importer)
+	assert.Equal(t, LayerPath("adapters/http"), violations[0].Imported)
+	assert.Equal(t, "github.com/test/project/adapters/http/internal", violations[0].ImportPath)
+
+	assert.Equal(t, filepath.Join(tmpDir, "ports/ports.go"), violations[1].File)
+	assert.Equal(t, 5, violations[1].Line)
+	assert.Equal(t, LayerPath("ports"), violations[1].Importer)
+	assert.Equal(t, LayerPath("adapters/http"), violations[1].Imported)
+}
+
+func TestAnalyzeFile_ParseError(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "broken.go")
+	writeTestFile(t, filePath, "this is not go")
+
+	analyzer := NewAnalyzer()
+	_, err := analyzer.AnalyzeFile(filePath, Package{Path: LayerPath("domain")}, &DependencyConfig{}, ModuleName("github.com/test/project"))
+	assert.IsType(t, SourceParseError{}, err)
+}
+
+func TestImportViolation_String(t *testing.T) {
+	violation := ImportViolation{
+		File:       "ports/ports.go",
+		Line:       5,
+		Column:     2,
+		Importer:   LayerPath("ports"),
+		Imported:   LayerPath("adapters/http"),
+		ImportPath: "github.com/test/project/adapters/http",
+	}
+	assert.Equal(t, "ports/ports.go:5:2: ports must not import github.com/test/project/adapters/http", violation.String())
+}
+
+func TestAnalyzeImports_Shared(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), "module github.com/test/project\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(tmpDir, "pkg/errors/errors.go"), `package errors
+
+import (
+	_ "errors"
+	_ "github.com/pkg/errors"
+)
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "pkg/logging/logging.go"), `package logging
+
+import (
+	_ "log/slog"
+	_ "github.com/test/project/pkg/errors"
+	_ "github.com/test/project/domain/entity"
+	_ "github.com/test/project/internal/util"
+)
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity/entity.go"), `package entity
+
+import (
+	_ "github.com/test/project/pkg/logging"
+)
+`)
+
+	config := &DependencyConfig{
+		Shared: []Package{
+			{Path: LayerPath("pkg/errors"), Level: 0},
+			{Path: LayerPath("pkg/logging"), Level: 1},
+		},
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+		},
+	}
+
+	analyzer := NewAnalyzer()
+	violations, err := analyzer.AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+
+	importPaths := make([]string, len(violations))
+	for i, violation := range violations {
+		importPaths[i] = violation.ImportPath
+	}
+	assert.Equal(t, []string{
+		"github.com/pkg/errors",
+		"github.com/test/project/domain/entity",
+		"github.com/test/project/internal/util",
+	}, importPaths)
+}
+
+func TestIsStandardLibrary(t *testing.T) {
+	assert.True(t, IsStandardLibrary("fmt"))
+	assert.True(t, IsStandardLibrary("net/http"))
+	assert.False(t, IsStandardLibrary("github.com/jackc/pgx"))
+	assert.False(t, IsStandardLibrary("google.golang.org/grpc"))
+}
+
+func TestAnalyzeImports_ExternalImports(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), "module github.com/test/project\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity/entity.go"), `package entity
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+)
+`)
+
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+		},
+		ExternalImports: []ExternalImportRule{
+			{Scope: RuleScope{Pattern: PackagePattern("domain/...")}, Pattern: ImportPattern("net/http")},
+			{Scope: RuleScope{Pattern: PackagePattern("domain/...")}, Pattern: ImportPattern("github.com/jackc/pgx/...")},
+		},
+	}
+
+	analyzer := NewAnalyzer()
+	violations, err := analyzer.AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+
+	require.Len(t, violations, 2)
+	assert.Equal(t, 5, violations[0].Line)
+	assert.Equal(t, "net/http", violations[0].ImportPath)
+	assert.Equal(t, LayerPath(""), violations[0].Imported)
+	assert.Equal(t, "domain/...: forbid net/http", violations[0].Reason)
+	assert.Equal(t, 7, violations[1].Line)
+	assert.Equal(t, "github.com/jackc/pgx/v5", violations[1].ImportPath)
+	assert.Contains(t, violations[1].String(), "entity.go:7:2: domain/entity must not import github.com/jackc/pgx/v5 (domain/...: forbid github.com/jackc/pgx/...)")
+}
+
+func TestAnalyzeImports_BannedSymbols(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), "module github.com/test/project\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity/entity.go"), `package entity
+
+import (
+	"log"
+	"math/rand"
+	"os"
+	stdtime "time"
+)
+
+func Now() stdtime.Time {
+	log.Println("allowed")
+	return stdtime.Now()
+}
+
+func Roll() int {
+	os := struct{ Getenv func(string) string }{}
+	_ = os.Getenv("shadowed")
+	log.Printf("%d", rand.Int())
+	return 0
+}
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "app/usecase/usecase.go"), `package usecase
+
+import "time"
+
+var _ = time.Now()
+`)
+
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Application layer"), Order: 2, Packages: []Package{{Path: LayerPath("app/usecase")}}},
+		},
+		BannedSymbols: []BannedSymbolRule{
+			{Scope: RuleScope{Layer: LayerName("Domain layer"), Order: 1}, Symbol: BannedSymbol{ImportPath: "time", Name: "Now"}},
+			{Scope: RuleScope{Layer: LayerName("Domain layer"), Order: 1}, Symbol: BannedSymbol{ImportPath: "math/rand", Name: "Int"}},
+			{Scope: RuleScope{Layer: LayerName("Domain layer"), Order: 1}, Symbol: BannedSymbol{ImportPath: "os", Name: "Getenv"}},
+			{Scope: RuleScope{Pattern: PackagePattern("domain/...")}, Symbol: BannedSymbol{ImportPath: "log", Name: "Printf"}},
+		},
+	}
+
+	analyzer := NewAnalyzer()
+	violations, err := analyzer.AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+
+	var found []string
+	for _, violation := range violations {
+		found = append(found, violation.String()[len(tmpDir)+1:])
+	}
+	assert.Equal(t, []string{
+		"domain/entity/entity.go:12:9: domain/entity must not use time.Now (1. Domain layer: ban time.Now)",
+		"domain/entity/entity.go:18:2: domain/entity must not use log.Printf (domain/...: ban log.Printf)",
+		"domain/entity/entity.go:18:19: domain/entity must not use math/rand.Int (1. Domain layer: ban math/rand.Int)",
+	}, found)
+}
+
+func TestGuessImportName(t *testing.T) {
+	tests := []struct {
+		importPath string
+		expected   string
+	}{
+		{"time", "time"},
+		{"math/rand", "rand"},
+		{"math/rand/v2", "rand"},
+		{"github.com/jackc/pgx/v5", "pgx"},
+		{"gopkg.in/yaml.v3", "yaml"},
+		{"github.com/mattn/go-isatty", "isatty"},
+		{"github.com/example/some-lib", "some_lib"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.importPath, func(t *testing.T) {
+			assert.Equal(t, tt.expected, GuessImportName(tt.importPath))
+		})
+	}
+}
+
+func TestClassifyFile(t *testing.T) {
+	assert.Equal(t, FileKindProduction, ClassifyFile("entity.go", "entity"))
+	assert.Equal(t, FileKindInternalTest, ClassifyFile("entity_test.go", "entity"))
+	assert.Equal(t, FileKindExternalTest, ClassifyFile("entity_test.go", "entity_test"))
+	assert.Equal(t, "external test", FileKindExternalTest.String())
+}
+
+func TestAnalyzeImports_TestMode(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), "module github.com/test/project\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity/entity.go"), `package entity
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity/entity_internal_test.go"), `package entity
+
+import _ "github.com/test/project/app/usecase"
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity/entity_test.go"), `package entity_test
+
+import (
+	_ "github.com/test/project/domain/entity"
+	_ "github.com/test/project/infra/fake"
+)
+`)
+	// Generated test files are never analyzed
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity", GeneratedTestFileName), `package entity_test
+
+import _ "github.com/test/project/app/usecase"
+`)
+
+	newConfig := func(mode TestMode) *DependencyConfig {
+		return &DependencyConfig{
+			Layers: []Layer{
+				{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+				{Name: LayerName("Application layer"), Order: 2, Packages: []Package{{Path: LayerPath("app/usecase")}}},
+				{Name: LayerName("Infra layer"), Order: 3, Packages: []Package{{Path: LayerPath("infra/fake")}}},
+			},
+			TestMode: mode,
+			TestImports: []TestImportRule{
+				{Scope: RuleScope{Layer: LayerName("Domain layer"), Order: 1}, Pattern: PackagePattern("infra/*")},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		mode     TestMode
+		expected []string
+		kinds    []FileKind
+	}{
+		{
+			name:     "default follows production rules",
+			mode:     "",
+			expected: []string{"entity_internal_test.go:3", "entity_test.go:5"},
+			kinds:    []FileKind{FileKindInternalTest, FileKindExternalTest},
+		},
+		{
+			name:     "production",
+			mode:     TestModeProduction,
+			expected: []string{"entity_internal_test.go:3", "entity_test.go:5"},
+			kinds:    []FileKind{FileKindInternalTest, FileKindExternalTest},
+		},
+		{
+			name:     "custom allows listed packages",
+			mode:     TestModeCustom,
+			expected: []string{"entity_internal_test.go:3"},
+			kinds:    []FileKind{FileKindInternalTest},
+		},
+		{
+			name:     "relaxed allows every package",
+			mode:     TestModeRelaxed,
+			expected: nil,
+			kinds:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyzer := NewAnalyzer()
+			violations, err := analyzer.AnalyzeImports(tmpDir, newConfig(tt.mode))
+			require.NoError(t, err)
+
+			var found []string
+			var kinds []FileKind
+			for _, violation := range violations {
+				found = append(found, fmt.Sprintf("%s:%d", filepath.Base(violation.File), violation.Line))
+				kinds = append(kinds, violation.FileKind)
+			}
+			assert.Equal(t, tt.expected, found)
+			assert.Equal(t, tt.kinds, kinds)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package dependency
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// Config is a parsed DEPENDENCY.md
+type Config = DependencyConfig
+
+// Parse reads the content of a DEPENDENCY.md
+func Parse(r io.Reader) (*Config, error) {
+	return NewParser().ParseDependencyContent(r)
+}
+
+// ParseFS reads a DEPENDENCY.md from a file system such as embed.FS
+func ParseFS(fsys fs.FS, name string) (*Config, error) {
+	return NewParser().ParseDependencyFS(fsys, name)
+}
+
+// Lookup returns the package listed with exactly the given path
+func (dc *DependencyConfig) Lookup(path LayerPath) (Package, bool) {
+	if index := dc.index; index != nil {
+		return index.lookup(dc, path)
+	}
+
+	for _, pkg := range dc.GetAllPackages() {
+		if pkg.Path == path {
+			return pkg, true
+		}
+	}
+	return Package{}, false
+}
+
+// DependenciesOf returns the sorted paths the listed package may depend on.
+// Unlike GetDependenciesForPackage, the level is taken from the config.
+func (dc *DependencyConfig) DependenciesOf(path LayerPath) ([]LayerPath, error) {
+	pkg, ok := dc.Lookup(path)
+	if !ok {
+		return nil, PackageNotFoundError{Path: path.String()}
+	}
+
+	dependencies := dc.GetDependenciesForPackage(pkg)
+	sort.Slice(dependencies, func(i, j int) bool {
+		return dependencies[i] < dependencies[j]
+	})
+	return dependencies, nil
+}
+
+// Allows reports whether code at the importer path may import the imported
+// path. Both paths resolve to their closest listed package, and paths that
+// are not listed at all are not constrained.
+func (dc *DependencyConfig) Allows(importer LayerPath, imported LayerPath) bool {
+	importerPackage, ok := dc.FindPackage(importer)
+	if !ok {
+		return true
+	}
+	importedPackage, ok := dc.FindPackage(imported)
+	if !ok {
+		return true
+	}
+	return dc.IsDependencyAllowed(importerPackage, importedPackage)
+}
+
+// GenerateOptions configures Generate
+type GenerateOptions struct {
+	// BaseDir holds go.mod, and package paths are relative to it
+	BaseDir string
+	// Config is the parsed DEPENDENCY.md
+	Config *Config
+	// TestFiles also generates dependency_gen_test.go for external test packages
+	TestFiles bool
+	// Output receives the generated files instead of BaseDir on disk
+	Output Output
+	// ModuleName is used instead of reading go.mod from BaseDir
+	ModuleName ModuleName
+	// FileName replaces dependency.gen.go as the generated file name
+	FileName string
+	// Force overwrites existing files that were not generated by this tool
+	Force bool
+	// Strict fails on listed packages whose directory is missing or holds
+	// no Go files, instead of creating them
+	Strict bool
+	// Workers bounds how many files are formatted and written at once,
+	// zero means GOMAXPROCS
+	Workers int
+}
+
+// Generate writes dependency.gen.go for every package listed in the config
+func Generate(ctx context.Context, opts GenerateOptions) error {
+	_, err := GenerateFiles(ctx, opts)
+	return err
+}
+
+// GenerateFiles is Generate that also reports which files were created,
+// updated, left unchanged or deleted
+func GenerateFiles(ctx context.Context, opts GenerateOptions) (*GenerateResult, error) {
+	if opts.Config == nil {
+		return nil, fmt.Errorf("generate: config is required")
+	}
+
+	generator := NewGenerator()
+	generator.GenerateTestFiles = opts.TestFiles
+	generator.Output = opts.Output
+	generator.ModuleName = opts.ModuleName
+	generator.FileName = opts.FileName
+	generator.Force = opts.Force
+	generator.Strict = opts.Strict
+	generator.Workers = opts.Workers
+	return generator.Run(ctx, opts.BaseDir, opts.Config)
+}
@@ -0,0 +1,122 @@
+package dependency
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const apiTestContent = `## Layers
+
+1. Domain layer
+2. Application layer
+
+## Packages in layers
+
+1. Domain layer
+  - domain/entity
+  - domain/valueobject
+    - domain/service
+2. Application layer
+  - app/usecase
+`
+
+func TestParse(t *testing.T) {
+	config, err := Parse(strings.NewReader(apiTestContent))
+	require.NoError(t, err)
+	require.Len(t, config.Layers, 2)
+	assert.Len(t, config.GetAllPackages(), 4)
+
+	_, err = Parse(strings.NewReader("## Layers\n1. \n"))
+	assert.Error(t, err)
+}
+
+func TestParseFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"DEPENDENCY.md": &fstest.MapFile{Data: []byte(apiTestContent)},
+	}
+
+	config, err := ParseFS(fsys, "DEPENDENCY.md")
+	require.NoError(t, err)
+	assert.Len(t, config.GetAllPackages(), 4)
+}
+
+func TestConfig_Lookup(t *testing.T) {
+	config, err := Parse(strings.NewReader(apiTestContent))
+	require.NoError(t, err)
+
+	pkg, ok := config.Lookup(LayerPath("domain/service"))
+	assert.True(t, ok)
+	assert.Equal(t, 1, pkg.Level)
+
+	_, ok = config.Lookup(LayerPath("domain/service/internal"))
+	assert.False(t, ok)
+}
+
+func TestConfig_DependenciesOf(t *testing.T) {
+	config, err := Parse(strings.NewReader(apiTestContent))
+	require.NoError(t, err)
+
+	// The level comes from the config, so nested packages see their siblings
+	dependencies, err := config.DependenciesOf(LayerPath("domain/service"))
+	require.NoError(t, err)
+	assert.Equal(t, []LayerPath{LayerPath("domain/entity"), LayerPath("domain/valueobject")}, dependencies)
+
+	_, err = config.DependenciesOf(LayerPath("infra/database"))
+	assert.Equal(t, PackageNotFoundError{Path: "infra/database"}, err)
+}
+
+func TestConfig_Allows(t *testing.T) {
+	config, err := Parse(strings.NewReader(apiTestContent))
+	require.NoError(t, err)
+
+	assert.True(t, config.Allows(LayerPath("app/usecase"), LayerPath("domain/service")))
+	assert.True(t, config.Allows(LayerPath("app/usecase/internal"), LayerPath("domain/entity/user")))
+	assert.False(t, config.Allows(LayerPath("domain/entity"), LayerPath("app/usecase")))
+	assert.False(t, config.Allows(LayerPath("domain/entity"), LayerPath("domain/service")))
+	assert.True(t, config.Allows(LayerPath("domain/entity"), LayerPath("unlisted")))
+}
+
+func TestGenerate(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module github.com/test/project\n"), 0644))
+
+	config, err := Parse(strings.NewReader(apiTestContent))
+	require.NoError(t, err)
+
+	err = Generate(context.Background(), GenerateOptions{BaseDir: tmpDir, Config: config})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(tmpDir, "app/usecase", GeneratedFileName))
+	assert.NoFileExists(t, filepath.Join(tmpDir, "app/usecase", GeneratedTestFileName))
+
+	err = Generate(context.Background(), GenerateOptions{BaseDir: tmpDir})
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = Generate(ctx, GenerateOptions{BaseDir: t.TempDir(), Config: config})
+	assert.Error(t, err)
+}
+
+func TestGenerateFiles(t *testing.T) {
+	config, err := Parse(strings.NewReader(apiTestContent))
+	require.NoError(t, err)
+
+	output := NewMemoryOutput()
+	opts := GenerateOptions{Config: config, Output: output, ModuleName: ModuleName("github.com/test/project")}
+
+	result, err := GenerateFiles(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 4)
+
+	result, err = GenerateFiles(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Len(t, result.Unchanged, 4)
+}
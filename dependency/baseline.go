@@ -0,0 +1,196 @@
+package dependency
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// BaselineFileName is the default name of the baseline file, next to DEPENDENCY.md
+const BaselineFileName = "dependency-baseline.json"
+
+// baselineVersion is the format version written to baseline files
+const baselineVersion = 1
+
+// Baseline records known violations so that only new ones fail a run
+type Baseline struct {
+	Version    int             `json:"version"`
+	Violations []BaselineEntry `json:"violations"`
+}
+
+// BaselineEntry is a recorded violation. Entries match violations by
+// importer, imported package and fingerprint; File and Message are kept
+// for people reading the baseline.
+type BaselineEntry struct {
+	Importer    LayerPath `json:"importer"`
+	Imported    string    `json:"imported"`
+	Fingerprint string    `json:"fingerprint"`
+	File        string    `json:"file"`
+	Message     string    `json:"message"`
+}
+
+// key identifies the entry when matching violations
+func (e BaselineEntry) key() string {
+	return fingerprintKey(string(e.Importer), e.Imported, e.Fingerprint)
+}
+
+func (e BaselineEntry) String() string {
+	return fmt.Sprintf("%s: %s", e.File, e.Message)
+}
+
+// NewBaseline records violations found by AnalyzeImports in baseDir.
+// Problems with //depcheck:ignore comments are left out, since fixing
+// the comment is always possible.
+func NewBaseline(baseDir string, violations []ImportViolation) *Baseline {
+	var recorded []ImportViolation
+	for _, violation := range violations {
+		if violation.Suppression == SuppressionNone {
+			recorded = append(recorded, violation)
+		}
+	}
+
+	baseline := &Baseline{
+		Version:    baselineVersion,
+		Violations: baselineEntries(baseDir, recorded),
+	}
+
+	sort.SliceStable(baseline.Violations, func(i, j int) bool {
+		a, b := baseline.Violations[i], baseline.Violations[j]
+		if a.Importer != b.Importer {
+			return a.Importer < b.Importer
+		}
+		if a.Imported != b.Imported {
+			return a.Imported < b.Imported
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+
+	return baseline
+}
+
+// baselineEntries returns an entry for each violation. The fingerprint
+// covers the rule, the file relative to baseDir, the import path and the
+// symbol, and counts identical violations, so it survives code moving
+// within a file but not a new import of the same kind.
+func baselineEntries(baseDir string, violations []ImportViolation) []BaselineEntry {
+	seen := map[string]int{}
+	entries := make([]BaselineEntry, len(violations))
+	for i, violation := range violations {
+		file := violation.File
+		if relative, err := filepath.Rel(baseDir, file); err == nil {
+			file = relative
+		}
+		file = filepath.ToSlash(file)
+
+		imported := string(violation.Imported)
+		if imported == "" {
+			imported = violation.ImportPath
+		}
+
+		key := fingerprintKey(violation.RuleID(), file, violation.ImportPath, violation.Symbol)
+		entries[i] = BaselineEntry{
+			Importer:    violation.Importer,
+			Imported:    imported,
+			Fingerprint: fingerprint(key, seen[key]),
+			File:        file,
+			Message:     violation.Message(),
+		}
+		seen[key]++
+	}
+	return entries
+}
+
+// ReadBaseline reads a baseline written by Write
+func ReadBaseline(r io.Reader) (*Baseline, error) {
+	var baseline Baseline
+	if err := json.NewDecoder(r).Decode(&baseline); err != nil {
+		return nil, fmt.Errorf("invalid baseline: %w", err)
+	}
+	if baseline.Version != baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline version %d", baseline.Version)
+	}
+	return &baseline, nil
+}
+
+// ReadBaselineFile reads the baseline at path, or returns nil when there is none
+func ReadBaselineFile(path string) (*Baseline, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadBaseline(file)
+}
+
+// WriteFile writes the baseline to path
+func (b *Baseline) WriteFile(path string) error {
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// Write encodes the baseline as indented JSON, which diffs well when committed
+func (b *Baseline) Write(w io.Writer) error {
+	if b.Violations == nil {
+		b.Violations = []BaselineEntry{}
+	}
+	data, err := marshalJSON(b)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Filter returns the violations that the baseline does not record, and
+// the recorded entries that no longer occur
+func (b *Baseline) Filter(baseDir string, violations []ImportViolation) ([]ImportViolation, []BaselineEntry) {
+	known := make(map[string]bool, len(b.Violations))
+	for _, entry := range b.Violations {
+		known[entry.key()] = true
+	}
+
+	current := map[string]bool{}
+	var added []ImportViolation
+	for i, entry := range baselineEntries(baseDir, violations) {
+		current[entry.key()] = true
+		if !known[entry.key()] {
+			added = append(added, violations[i])
+		}
+	}
+
+	var fixed []BaselineEntry
+	for _, entry := range b.Violations {
+		if !current[entry.key()] {
+			fixed = append(fixed, entry)
+		}
+	}
+
+	return added, fixed
+}
+
+// BaselineFindings returns an info finding for every baseline entry that
+// no longer occurs. baselineFile is the path of the baseline they point to.
+func BaselineFindings(baselineFile string, fixed []BaselineEntry) []Finding {
+	findings := make([]Finding, len(fixed))
+	for i, entry := range fixed {
+		findings[i] = Finding{
+			RuleID:   RuleBaselineFixed,
+			Severity: DefaultSeverity(RuleBaselineFixed),
+			Message:  fmt.Sprintf("%s in %s is fixed and can be removed from the baseline", entry.Message, entry.File),
+			File:     filepath.ToSlash(baselineFile),
+		}
+	}
+	return findings
+}
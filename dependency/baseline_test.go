@@ -0,0 +1,106 @@
+package dependency
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func baselineViolations(baseDir string) []ImportViolation {
+	return []ImportViolation{
+		{File: filepath.Join(baseDir, "domain/entity/user.go"), Line: 5, Importer: "domain/entity", Imported: "infra/db", ImportPath: "example.com/app/infra/db"},
+		{File: filepath.Join(baseDir, "domain/entity/user.go"), Line: 14, Importer: "domain/entity", ImportPath: "time", Symbol: "time.Now", Reason: "use the clock"},
+		{File: filepath.Join(baseDir, "domain/entity/user.go"), Line: 20, Importer: "domain/entity", ImportPath: "time", Symbol: "time.Now", Reason: "use the clock"},
+		{File: filepath.Join(baseDir, "app/usecase/order.go"), Line: 7, Importer: "app/usecase", Imported: "infra/db", ImportPath: "example.com/app/infra/db"},
+	}
+}
+
+func TestBaseline_RoundTrip(t *testing.T) {
+	baseline := NewBaseline("project", baselineViolations("project"))
+	require.Len(t, baseline.Violations, 4)
+	assert.Equal(t, LayerPath("app/usecase"), baseline.Violations[0].Importer)
+	assert.Equal(t, "infra/db", baseline.Violations[0].Imported)
+	assert.Equal(t, "app/usecase/order.go", baseline.Violations[0].File)
+
+	var buf bytes.Buffer
+	require.NoError(t, baseline.Write(&buf))
+
+	read, err := ReadBaseline(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, baseline, read)
+}
+
+func TestNewBaseline_SkipsSuppressionProblems(t *testing.T) {
+	violations := append(baselineViolations("project"),
+		ImportViolation{File: "project/domain/entity/user.go", Line: 3, Importer: "domain/entity", Suppression: SuppressionInvalid},
+		ImportViolation{File: "project/domain/entity/user.go", Line: 9, Importer: "domain/entity", ImportPath: "time", Suppression: SuppressionUnused},
+	)
+
+	baseline := NewBaseline("project", violations)
+	assert.Equal(t, NewBaseline("project", baselineViolations("project")), baseline)
+
+	// They are reported even when the baseline is applied
+	added, removed := baseline.Filter("project", violations)
+	assert.Equal(t, violations[4:], added)
+	assert.Empty(t, removed)
+}
+
+func TestBaseline_Filter(t *testing.T) {
+	baseline := NewBaseline("project", baselineViolations("project"))
+
+	// Analyzed from another directory, with lines shifted, one violation
+	// fixed and one more use of a banned symbol
+	violations := baselineViolations("/src/project")
+	for i := range violations {
+		violations[i].Line += 3
+	}
+	fixed := violations[3]
+	violations = append(violations[:3],
+		ImportViolation{File: "/src/project/domain/entity/user.go", Line: 30, Importer: "domain/entity", ImportPath: "time", Symbol: "time.Now", Reason: "use the clock"},
+		ImportViolation{File: "/src/project/domain/entity/order.go", Line: 4, Importer: "domain/entity", Imported: "infra/db", ImportPath: "example.com/app/infra/db"},
+	)
+
+	added, removed := baseline.Filter("/src/project", violations)
+
+	assert.Equal(t, violations[3:], added)
+	require.Len(t, removed, 1)
+	assert.Equal(t, fixed.Importer, removed[0].Importer)
+	assert.Equal(t, "app/usecase/order.go", removed[0].File)
+}
+
+func TestBaseline_WriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), BaselineFileName)
+
+	missing, err := ReadBaselineFile(path)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	baseline := NewBaseline("project", baselineViolations("project"))
+	require.NoError(t, baseline.WriteFile(path))
+	read, err := ReadBaselineFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, baseline, read)
+}
+
+func TestReadBaseline_Invalid(t *testing.T) {
+	_, err := ReadBaseline(strings.NewReader("not json"))
+	assert.Error(t, err)
+
+	_, err = ReadBaseline(strings.NewReader(`{"version": 2, "violations": []}`))
+	assert.EqualError(t, err, "unsupported baseline version 2")
+}
+
+func TestBaselineFindings(t *testing.T) {
+	baseline := NewBaseline("project", baselineViolations("project")[:1])
+
+	assert.Equal(t, []Finding{{
+		RuleID:   RuleBaselineFixed,
+		Severity: SeverityInfo,
+		Message:  "domain/entity must not import example.com/app/infra/db in domain/entity/user.go is fixed and can be removed from the baseline",
+		File:     "project/dependency-baseline.json",
+	}}, BaselineFindings(filepath.Join("project", BaselineFileName), baseline.Violations))
+}
@@ -0,0 +1,104 @@
+package dependency_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/handlename/go-package-dependency/dependency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The v1 API that downstream tools depend on. Changing any of these
+// signatures breaks the build of this file and needs a new major version.
+var (
+	_ func(io.Reader) (*dependency.Config, error)                                    = dependency.Parse
+	_ func(context.Context, dependency.GenerateOptions) error                        = dependency.Generate
+	_ func(*dependency.Config, dependency.LayerPath) (dependency.Package, bool)      = (*dependency.Config).Lookup
+	_ func(*dependency.Config, dependency.LayerPath) ([]dependency.LayerPath, error) = (*dependency.Config).DependenciesOf
+	_ func(*dependency.Config, dependency.LayerPath, dependency.LayerPath) bool      = (*dependency.Config).Allows
+	_ func(*dependency.Config) []dependency.Package                                  = (*dependency.Config).GetAllPackages
+	_ func(*dependency.Config, dependency.LayerName) []dependency.Package            = (*dependency.Config).GetPackagesByLayer
+	_ func(*dependency.Config, dependency.Package) []dependency.LayerPath            = (*dependency.Config).GetDependenciesForPackage
+
+	_ = dependency.GenerateOptions{BaseDir: "", Config: nil, TestFiles: false}
+	_ = dependency.Package{Path: "", Level: 0}
+	_ = dependency.Layer{Name: "", Order: 0, Packages: nil}
+)
+
+const compatV1Dir = "testdata/compat/v1"
+
+func TestCompatV1_Parse(t *testing.T) {
+	file, err := os.Open(filepath.Join(compatV1Dir, "DEPENDENCY.md"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	config, err := dependency.Parse(file)
+	require.NoError(t, err)
+
+	var paths []dependency.LayerPath
+	for _, pkg := range config.GetAllPackages() {
+		paths = append(paths, pkg.Path)
+	}
+	assert.Equal(t, []dependency.LayerPath{
+		"pkg/errors",
+		"domain/entity",
+		"domain/valueobject",
+		"domain/service",
+		"app/service",
+		"app/usecase",
+		"infra/database",
+	}, paths)
+
+	dependencies, err := config.DependenciesOf("app/usecase")
+	require.NoError(t, err)
+	assert.Equal(t, []dependency.LayerPath{
+		"app/service",
+		"domain/entity",
+		"domain/service",
+		"domain/valueobject",
+		"pkg/errors",
+	}, dependencies)
+
+	assert.True(t, config.Allows("infra/database", "app/usecase"))
+	assert.False(t, config.Allows("app/usecase", "infra/database"))
+}
+
+func TestCompatV1_Generate(t *testing.T) {
+	file, err := os.Open(filepath.Join(compatV1Dir, "DEPENDENCY.md"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	config, err := dependency.Parse(file)
+	require.NoError(t, err)
+
+	goMod, err := os.ReadFile(filepath.Join(compatV1Dir, "go.mod"))
+	require.NoError(t, err)
+
+	baseDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, "go.mod"), goMod, 0644))
+
+	err = dependency.Generate(context.Background(), dependency.GenerateOptions{BaseDir: baseDir, Config: config})
+	require.NoError(t, err)
+
+	// Every generated file must match its golden copy byte for byte
+	expectedDir := filepath.Join(compatV1Dir, "expected")
+	for _, pkg := range config.GetAllPackages() {
+		relative := filepath.Join(pkg.Path.String(), dependency.GeneratedFileName)
+
+		actual, err := os.ReadFile(filepath.Join(baseDir, relative))
+		require.NoError(t, err)
+
+		if os.Getenv("UPDATE_GOLDEN") != "" {
+			require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(expectedDir, relative)), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(expectedDir, relative), actual, 0644))
+		}
+
+		expected, err := os.ReadFile(filepath.Join(expectedDir, relative))
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(actual), "golden mismatch for %s", relative)
+	}
+}
@@ -0,0 +1,151 @@
+package dependency
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Markers of the block of DEPENDENCY.md that holds the generated diagram.
+// The parser skips everything between them.
+const (
+	DiagramBegin = "<!-- depgraph:begin -->"
+	DiagramEnd   = "<!-- depgraph:end -->"
+)
+
+// Mermaid returns a Mermaid flowchart of the layers, drawn from the top
+// layer down, with arrows pointing to what each layer and package may
+// import. Arrows implied by other arrows are left out.
+func (dc *DependencyConfig) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart BT\n")
+
+	node := 0
+	subgraph := func(id string, title string, packages []Package, isolated bool) {
+		fmt.Fprintf(&b, "  subgraph %s[\"%s\"]\n", id, mermaidLabel(title))
+		first := node
+		for _, pkg := range packages {
+			fmt.Fprintf(&b, "    p%d[\"%s\"]\n", node, mermaidLabel(pkg.Path.String()))
+			node++
+		}
+		if !isolated {
+			for _, edge := range siblingEdges(packages) {
+				fmt.Fprintf(&b, "    p%d --> p%d\n", first+edge[0], first+edge[1])
+			}
+		}
+		b.WriteString("  end\n")
+	}
+
+	var previous string
+	if len(dc.Shared) > 0 {
+		subgraph("shared", "Shared", dc.Shared, false)
+		previous = "shared"
+	}
+
+	// Each layer may import every layer above it, so one arrow to the
+	// layer right above shows the order
+	layers := slices.Clone(dc.Layers)
+	slices.SortStableFunc(layers, func(a, b Layer) int {
+		return cmp.Compare(a.Order, b.Order)
+	})
+	for _, layer := range layers {
+		id := fmt.Sprintf("l%d", layer.Order)
+		title := fmt.Sprintf("%d. %s", layer.Order, layer.Name)
+		if layer.Isolated {
+			title += " (isolated)"
+		}
+		subgraph(id, title, layer.Packages, layer.Isolated)
+		if previous != "" {
+			fmt.Fprintf(&b, "  %s --> %s\n", id, previous)
+		}
+		previous = id
+	}
+
+	return b.String()
+}
+
+// siblingEdges returns the arrows between packages of the same list as
+// pairs of indexes, from a package to one it may import. Arrows implied
+// by a path through other packages are left out.
+func siblingEdges(packages []Package) [][2]int {
+	index := make(map[LayerPath]int, len(packages))
+	for i, pkg := range packages {
+		index[pkg.Path] = i
+	}
+
+	allowed := make([][]bool, len(packages))
+	for i, pkg := range packages {
+		allowed[i] = make([]bool, len(packages))
+		for _, path := range siblingDependencies(packages, pkg, i) {
+			allowed[i][index[path]] = true
+		}
+	}
+
+	// reachable is the transitive closure of allowed
+	reachable := make([][]bool, len(packages))
+	for i := range allowed {
+		reachable[i] = slices.Clone(allowed[i])
+	}
+	for k := range reachable {
+		for i := range reachable {
+			if !reachable[i][k] {
+				continue
+			}
+			for j := range reachable {
+				reachable[i][j] = reachable[i][j] || reachable[k][j]
+			}
+		}
+	}
+
+	var edges [][2]int
+	for i := range allowed {
+		for j := range allowed {
+			if !allowed[i][j] {
+				continue
+			}
+			implied := false
+			for k := range allowed {
+				if k != i && k != j && reachable[i][k] && reachable[k][j] {
+					implied = true
+					break
+				}
+			}
+			if !implied {
+				edges = append(edges, [2]int{i, j})
+			}
+		}
+	}
+	return edges
+}
+
+// mermaidLabel escapes text for a quoted Mermaid label
+func mermaidLabel(text string) string {
+	return strings.ReplaceAll(text, `"`, "#quot;")
+}
+
+// UpdateDiagram replaces what is between the diagram markers of a
+// DEPENDENCY.md with a diagram of config. Content without the markers is
+// returned unchanged.
+func UpdateDiagram(content []byte, config *DependencyConfig) ([]byte, error) {
+	begin := bytes.Index(content, []byte(DiagramBegin))
+	end := bytes.Index(content, []byte(DiagramEnd))
+	switch {
+	case begin < 0 && end < 0:
+		return content, nil
+	case begin < 0:
+		return nil, errors.New(DiagramEnd + " without " + DiagramBegin)
+	case end < 0:
+		return nil, errors.New(DiagramBegin + " without " + DiagramEnd)
+	case end < begin:
+		return nil, errors.New(DiagramBegin + " must come before " + DiagramEnd)
+	}
+
+	var updated bytes.Buffer
+	updated.Write(content[:begin+len(DiagramBegin)])
+	updated.WriteString("\n```mermaid\n" + config.Mermaid() + "```\n")
+	updated.Write(content[end:])
+	return updated.Bytes(), nil
+}
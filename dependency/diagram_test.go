@@ -0,0 +1,140 @@
+package dependency
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const diagramContent = `# Dependencies
+
+<!-- depgraph:begin -->
+stale
+<!-- depgraph:end -->
+
+## Shared
+
+- pkg/errors
+
+## Layers
+
+1. Domain layer
+2. Infra layer
+
+## Packages in layers
+
+1. Domain layer
+  - domain/entity
+    - domain/service
+2. Infra layer
+  - infra/db
+  - infra/cache
+`
+
+func TestDependencyConfig_Mermaid(t *testing.T) {
+	config, err := NewParser().ParseDependencyContent(strings.NewReader(diagramContent))
+	require.NoError(t, err)
+	config.Layers[1].Isolated = true
+
+	assert.Equal(t, `flowchart BT
+  subgraph shared["Shared"]
+    p0["pkg/errors"]
+  end
+  subgraph l1["1. Domain layer"]
+    p1["domain/entity"]
+    p2["domain/service"]
+    p2 --> p1
+  end
+  l1 --> shared
+  subgraph l2["2. Infra layer (isolated)"]
+    p3["infra/db"]
+    p4["infra/cache"]
+  end
+  l2 --> l1
+`, config.Mermaid())
+}
+
+func TestDependencyConfig_Mermaid_NestedLevels(t *testing.T) {
+	// b may import a and c, and c may import a. b --> a goes through c.
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: "Infra layer", Order: 2, Packages: []Package{{Path: "infra/db"}}},
+			{Name: "Domain layer", Order: 1, Packages: []Package{
+				{Path: "a", Level: 0},
+				{Path: "b", Level: 1},
+				{Path: "c", Level: 0},
+			}},
+		},
+	}
+
+	assert.Equal(t, `flowchart BT
+  subgraph l1["1. Domain layer"]
+    p0["a"]
+    p1["b"]
+    p2["c"]
+    p1 --> p2
+    p2 --> p0
+  end
+  subgraph l2["2. Infra layer"]
+    p3["infra/db"]
+  end
+  l2 --> l1
+`, config.Mermaid())
+}
+
+func TestSiblingEdges(t *testing.T) {
+	packages := []Package{
+		{Path: "a", Level: 0},
+		{Path: "b", Level: 1},
+		{Path: "c", Level: 0},
+		{Path: "d", Level: 1},
+	}
+	// d may import a, b and c; b may import a and c; c may import a
+	assert.Equal(t, [][2]int{{1, 2}, {2, 0}, {3, 1}}, siblingEdges(packages))
+	assert.Empty(t, siblingEdges(packages[:1]))
+}
+
+func TestUpdateDiagram(t *testing.T) {
+	config, err := NewParser().ParseDependencyContent(strings.NewReader(diagramContent))
+	require.NoError(t, err)
+
+	updated, err := UpdateDiagram([]byte(diagramContent), config)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(diagramContent, "stale\n", "```mermaid\n"+config.Mermaid()+"```\n", 1), string(updated))
+
+	// The parser skips the diagram, and updating again changes nothing
+	reparsed, err := NewParser().ParseDependencyContent(strings.NewReader(string(updated)))
+	require.NoError(t, err)
+	assert.Equal(t, config.Layers, reparsed.Layers)
+	again, err := UpdateDiagram(updated, reparsed)
+	require.NoError(t, err)
+	assert.Equal(t, string(updated), string(again))
+
+	unchanged, err := UpdateDiagram([]byte("## Layers\n"), config)
+	require.NoError(t, err)
+	assert.Equal(t, "## Layers\n", string(unchanged))
+}
+
+func TestUpdateDiagram_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"<!-- depgraph:end -->\n":                          "<!-- depgraph:end --> without <!-- depgraph:begin -->",
+		"<!-- depgraph:begin -->\n":                        "<!-- depgraph:begin --> without <!-- depgraph:end -->",
+		"<!-- depgraph:end -->\n<!-- depgraph:begin -->\n": "<!-- depgraph:begin --> must come before <!-- depgraph:end -->",
+	}
+	for content, expected := range tests {
+		_, err := UpdateDiagram([]byte(content), &DependencyConfig{})
+		assert.EqualError(t, err, expected)
+	}
+}
+
+func TestParseDependencyContent_Diagram(t *testing.T) {
+	_, err := NewParser().ParseDependencyContent(strings.NewReader("## Layers\n\n<!-- depgraph:begin -->\n## Banned symbols\n1. Domain layer\n"))
+	assert.EqualError(t, err, "line 3: <!-- depgraph:begin --> without <!-- depgraph:end -->")
+
+	config, err := NewParser().ParseDependencyContent(strings.NewReader("## Layers\n\n<!-- depgraph:begin -->\n1. Ignored layer\n<!-- depgraph:end -->\n1. Domain layer\n"))
+	require.NoError(t, err)
+	require.Len(t, config.Layers, 1)
+	assert.Equal(t, LayerName("Domain layer"), config.Layers[0].Name)
+}
@@ -0,0 +1,20 @@
+// Package dependency parses DEPENDENCY.md, answers questions about the
+// layering it describes, and generates the dependency.gen.go files that
+// enforce it.
+//
+// The stable API consists of Parse, Config with its query methods
+// (Lookup, DependenciesOf, Allows, GetAllPackages, GetPackagesByLayer),
+// Generate with GenerateOptions, and the Analyzer for import checks.
+// Its compatibility is pinned by the compat tests of this package.
+//
+//	config, err := dependency.Parse(file)
+//	if err != nil {
+//		return err
+//	}
+//	deps, err := config.DependenciesOf("app/usecase")
+//	...
+//	err = dependency.Generate(ctx, dependency.GenerateOptions{
+//		BaseDir: ".",
+//		Config:  config,
+//	})
+package dependency
@@ -0,0 +1,399 @@
+package dependency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+const (
+	// GeneratedFileName is the name of the file generated in each package
+	GeneratedFileName = "dependency.gen.go"
+	// GeneratedTestFileName is the name of the file generated for external test packages
+	GeneratedTestFileName = "dependency_gen_test.go"
+)
+
+type Generator struct {
+	// GenerateTestFiles also emits dependency_gen_test.go for the external
+	// test package, importing what the test mode allows tests to depend on
+	GenerateTestFiles bool
+	// Output receives the generated files. When nil, files are written
+	// to the base directory on disk.
+	Output Output
+	// ModuleName is used instead of reading go.mod from the base directory
+	ModuleName ModuleName
+	// FileName replaces GeneratedFileName. The test file name is derived
+	// from it, e.g. deps.gen.go generates deps_gen_test.go.
+	FileName string
+	// Force overwrites existing files that lack the generated code header
+	Force bool
+	// Strict fails when a listed package directory does not exist or holds
+	// no Go files, instead of creating it
+	Strict bool
+	// Workers bounds how many files are formatted and written at once.
+	// Zero means GOMAXPROCS.
+	Workers int
+}
+
+func NewGenerator() *Generator {
+	return &Generator{}
+}
+
+// generatedHeader starts every generated file. Only files that start with it
+// are overwritten or removed as stale.
+const generatedHeader = "// Code generated by go-package-dependency. DO NOT EDIT."
+
+func isGenerated(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(generatedHeader))
+}
+
+// fileNames returns the names of the generated file and the generated test file
+func (g *Generator) fileNames() (string, string, error) {
+	if g.FileName == "" {
+		return GeneratedFileName, GeneratedTestFileName, nil
+	}
+
+	name := g.FileName
+	switch {
+	case name != path.Base(name) || strings.ContainsAny(name, `/\`):
+		return "", "", InvalidFileNameError{Name: name, Reason: "must not contain a directory"}
+	case !strings.HasSuffix(name, ".go") || name == ".go":
+		return "", "", InvalidFileNameError{Name: name, Reason: "must end with .go"}
+	case strings.HasSuffix(name, "_test.go"):
+		return "", "", InvalidFileNameError{Name: name, Reason: "must not end with _test.go"}
+	case strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_"):
+		return "", "", InvalidFileNameError{Name: name, Reason: "must not start with . or _, which the go command ignores"}
+	}
+
+	return name, testFileName(name), nil
+}
+
+// testFileName derives the test file name, e.g. dependency.gen.go becomes dependency_gen_test.go
+func testFileName(name string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(name, ".go"), ".", "_") + "_test.go"
+}
+
+// GenerateResult lists the files a run touched, by slash-separated name
+// relative to the base directory
+type GenerateResult struct {
+	Created   []string
+	Updated   []string
+	Unchanged []string
+	Deleted   []string
+	// Warnings lists problems whose severity is below error, such as missing
+	// package directories in strict mode
+	Warnings []error
+}
+
+func (r *GenerateResult) String() string {
+	return fmt.Sprintf("%d created, %d updated, %d unchanged, %d deleted",
+		len(r.Created), len(r.Updated), len(r.Unchanged), len(r.Deleted))
+}
+
+// generatedFile is a formatted file that a run writes
+type generatedFile struct {
+	name    string
+	content []byte
+}
+
+func (g *Generator) GenerateDependencyFiles(baseDir string, config *DependencyConfig) error {
+	return g.GenerateDependencyFilesContext(context.Background(), baseDir, config)
+}
+
+// GenerateDependencyFilesContext is GenerateDependencyFiles that stops
+// before the next package once ctx is done
+func (g *Generator) GenerateDependencyFilesContext(ctx context.Context, baseDir string, config *DependencyConfig) error {
+	_, err := g.Run(ctx, baseDir, config)
+	return err
+}
+
+// Run generates the files and reports what changed. When the output already
+// holds files, identical files are not rewritten and generated files that
+// the config no longer produces are removed.
+//
+// A run is all-or-nothing: every file is generated and formatted before the
+// first write, and when a write fails the files already written are restored
+// and created directories removed. The returned GenerateError lists every
+// failure.
+func (g *Generator) Run(ctx context.Context, baseDir string, config *DependencyConfig) (*GenerateResult, error) {
+	moduleName := g.ModuleName
+	if moduleName == "" {
+		// Get module name from go.mod
+		parser := NewParser()
+		name, err := parser.GetModuleName(filepath.Join(baseDir, "go.mod"))
+		if err != nil {
+			return nil, err
+		}
+		moduleName = name
+	}
+
+	output := g.Output
+	if output == nil {
+		output = NewOSOutput(baseDir)
+	}
+
+	fileName, testFileName, err := g.fileNames()
+	if err != nil {
+		return nil, err
+	}
+
+	// The normalized copy is private to this run, so it can hold the index
+	config, invalid := config.normalized()
+	if len(invalid) > 0 {
+		return nil, GenerateError{Errors: invalid}
+	}
+	config.index = newPackageIndex(config)
+
+	tx := newTransaction(output, baseDir)
+
+	packages := config.GetAllPackages()
+	var warnings []error
+	if g.Strict {
+		existing := tx.existing
+		if existing == nil {
+			existing = os.DirFS(baseDir)
+		}
+		missing, err := checkPackageDirs(existing, packages, fileName, testFileName)
+		if err != nil {
+			return nil, err
+		}
+		// Below the error severity, missing packages are reported without
+		// failing the run, and no files are written for them
+		if len(missing) > 0 && config.Severity(RuleMissingPackage) == SeverityError {
+			return nil, GenerateError{Errors: missing}
+		}
+		warnings = missing
+		packages = withoutMissing(packages, missing)
+	}
+
+	files, err := g.planFiles(ctx, baseDir, config, packages, moduleName, fileName, testFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, result, err := g.stageChanges(ctx, tx.existing, baseDir, files, fileName, testFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := forEach(ctx, g.Workers, len(changes), true, func(i int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return tx.apply(changes[i])
+	})
+	if len(errs) == 0 {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, GenerateError{Errors: append(errs, tx.rollback()...)}
+	}
+
+	result.Warnings = warnings
+	return result, nil
+}
+
+// stageChanges compares the planned files with the existing ones. When
+// existing is nil every file is written and nothing is removed. Existing
+// files without the generated code header are refused unless Force is set.
+func (g *Generator) stageChanges(ctx context.Context, existing fs.FS, baseDir string, files []generatedFile, fileName string, testFileName string) ([]change, *GenerateResult, error) {
+	result := &GenerateResult{}
+	var (
+		changes []change
+		foreign []error
+	)
+
+	// Read the existing files concurrently, then compare them in order
+	existingContents := make([][]byte, len(files))
+	if existing != nil {
+		errs := forEach(ctx, g.Workers, len(files), true, func(i int) error {
+			content, err := fs.ReadFile(existing, files[i].name)
+			// ENOTDIR means a parent is a file, so the directory creation will report it
+			if err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, syscall.ENOTDIR) {
+				return err
+			}
+			existingContents[i] = content
+			return nil
+		})
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		if len(errs) > 0 {
+			return nil, nil, errs[0]
+		}
+	}
+
+	for i, file := range files {
+		previous := existingContents[i]
+		if previous != nil {
+			if bytes.Equal(previous, file.content) {
+				result.Unchanged = append(result.Unchanged, file.name)
+				continue
+			}
+			if !isGenerated(previous) && !g.Force {
+				foreign = append(foreign, ForeignFileError{Path: filepath.Join(baseDir, filepath.FromSlash(file.name))})
+				continue
+			}
+		}
+
+		changes = append(changes, change{name: file.name, content: file.content, previous: previous})
+		if previous == nil {
+			result.Created = append(result.Created, file.name)
+		} else {
+			result.Updated = append(result.Updated, file.name)
+		}
+	}
+
+	if len(foreign) > 0 {
+		return nil, nil, GenerateError{Errors: foreign}
+	}
+
+	if existing == nil {
+		return changes, result, nil
+	}
+
+	stale, err := findStaleFiles(existing, files, fileName, testFileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, name := range stale {
+		previous, err := fs.ReadFile(existing, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = append(changes, change{name: name, previous: previous})
+		result.Deleted = append(result.Deleted, name)
+	}
+
+	return changes, result, nil
+}
+
+// planFiles generates and formats the files of packages before anything is
+// written. Formatting failures of all files are returned together.
+func (g *Generator) planFiles(ctx context.Context, baseDir string, config *DependencyConfig, packages []Package, moduleName ModuleName, fileName string, testFileName string) ([]generatedFile, error) {
+	filesPerPackage := 1
+	if g.GenerateTestFiles {
+		filesPerPackage = 2
+	}
+
+	// Each package has its dependency.gen.go at an even slot and, with test
+	// files, its test file at the following odd slot
+	files := make([]generatedFile, len(packages)*filesPerPackage)
+	errs := forEach(ctx, g.Workers, len(files), false, func(i int) error {
+		pkg := packages[i/filesPerPackage]
+
+		if i%filesPerPackage == 0 {
+			// Get dependencies for this package
+			dependencies := config.GetDependenciesForPackage(pkg)
+
+			// Generate the dependency file content
+			content := g.GenerateDependencyFileContent(pkg.Path, dependencies, moduleName)
+			file, err := formatFile(baseDir, path.Join(pkg.Path.String(), fileName), content)
+			files[i] = file
+			return err
+		}
+
+		testDependencies := config.GetTestDependenciesForPackage(pkg)
+		testContent := g.GenerateTestDependencyFileContent(pkg.Path, testDependencies, moduleName)
+		testFile, err := formatFile(baseDir, path.Join(pkg.Path.String(), testFileName), testContent)
+		files[i] = testFile
+		return err
+	})
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, GenerateError{Errors: errs}
+	}
+	return files, nil
+}
+
+func formatFile(baseDir string, name string, content string) (generatedFile, error) {
+	formattedContent, err := format.Source([]byte(content))
+	if err != nil {
+		return generatedFile{}, FileFormatError{Path: filepath.Join(baseDir, filepath.FromSlash(name)), Err: err}
+	}
+	return generatedFile{name: name, content: formattedContent}, nil
+}
+
+// findStaleFiles returns the generated files in fsys that are not in files.
+// Directories the go command ignores and nested modules are skipped.
+func findStaleFiles(fsys fs.FS, files []generatedFile, fileName string, testFileName string) ([]string, error) {
+	planned := make(map[string]bool, len(files))
+	for _, file := range files {
+		planned[file.name] = true
+	}
+
+	var stale []string
+	err := walkModule(fsys, func(name string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+
+		if d.Name() != fileName && d.Name() != testFileName || planned[name] {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		if isGenerated(content) {
+			stale = append(stale, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return stale, nil
+}
+
+func (g *Generator) GenerateDependencyFileContent(currentPackagePath LayerPath, dependencies []LayerPath, moduleName ModuleName) string {
+	return g.generateContent(GetPackageName(currentPackagePath), dependencies, moduleName)
+}
+
+// GenerateTestDependencyFileContent generates the file for the external test
+// package, which may also import the package under test
+func (g *Generator) GenerateTestDependencyFileContent(currentPackagePath LayerPath, dependencies []LayerPath, moduleName ModuleName) string {
+	packageName := PackageName(GetPackageName(currentPackagePath).String() + "_test")
+	return g.generateContent(packageName, append([]LayerPath{currentPackagePath}, dependencies...), moduleName)
+}
+
+func (g *Generator) generateContent(packageName PackageName, dependencies []LayerPath, moduleName ModuleName) string {
+	// Sort dependencies for consistent output
+	sort.Slice(dependencies, func(i, j int) bool {
+		return string(dependencies[i]) < string(dependencies[j])
+	})
+
+	var imports []string
+	for _, depPath := range dependencies {
+		importPath := fmt.Sprintf("%s/%s", moduleName.String(), depPath.String())
+		imports = append(imports, fmt.Sprintf("_ \"%s\"", importPath))
+	}
+
+	content := fmt.Sprintf("%s\n\npackage %s\n", generatedHeader, packageName.String())
+
+	if len(imports) > 0 {
+		content += "\nimport (\n"
+		for _, imp := range imports {
+			content += imp + "\n"
+		}
+		content += ")\n"
+	}
+
+	return content
+}
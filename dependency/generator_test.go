@@ -0,0 +1,690 @@
+package dependency
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDependencyFileContent(t *testing.T) {
+	tests := []struct {
+		name         string
+		packagePath  LayerPath
+		dependencies []LayerPath
+		moduleName   ModuleName
+		expected     string
+	}{
+		{
+			name:         "package with no dependencies",
+			packagePath:  LayerPath("domain/entity"),
+			dependencies: []LayerPath{},
+			moduleName:   ModuleName("github.com/example/project"),
+			expected: `// Code generated by go-package-dependency. DO NOT EDIT.
+
+package entity
+`,
+		},
+		{
+			name:        "package with single dependency",
+			packagePath: LayerPath("domain/service"),
+			dependencies: []LayerPath{
+				LayerPath("domain/entity"),
+			},
+			moduleName: ModuleName("github.com/example/project"),
+			expected: `// Code generated by go-package-dependency. DO NOT EDIT.
+
+package service
+
+import (
+_ "github.com/example/project/domain/entity"
+)
+`,
+		},
+		{
+			name:        "package with multiple dependencies",
+			packagePath: LayerPath("app/usecase"),
+			dependencies: []LayerPath{
+				LayerPath("app/service"),
+				LayerPath("domain/entity"),
+				LayerPath("domain/service"),
+				LayerPath("domain/valueobject"),
+			},
+			moduleName: ModuleName("github.com/example/project"),
+			expected: `// Code generated by go-package-dependency. DO NOT EDIT.
+
+package usecase
+
+import (
+_ "github.com/example/project/app/service"
+_ "github.com/example/project/domain/entity"
+_ "github.com/example/project/domain/service"
+_ "github.com/example/project/domain/valueobject"
+)
+`,
+		},
+		{
+			name:        "nested package path",
+			packagePath: LayerPath("infra/database/mysql"),
+			dependencies: []LayerPath{
+				LayerPath("domain/entity"),
+			},
+			moduleName: ModuleName("github.com/example/project"),
+			expected: `// Code generated by go-package-dependency. DO NOT EDIT.
+
+package mysql
+
+import (
+_ "github.com/example/project/domain/entity"
+)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator := NewGenerator()
+			result := generator.GenerateDependencyFileContent(tt.packagePath, tt.dependencies, tt.moduleName)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGetDependenciesForPackage(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{
+				Name:  LayerName("Domain layer"),
+				Order: 1,
+				Packages: []Package{
+					{Path: LayerPath("domain/entity"), Level: 0},
+					{Path: LayerPath("domain/valueobject"), Level: 0},
+					{Path: LayerPath("domain/service"), Level: 1},
+				},
+			},
+			{
+				Name:  LayerName("Application layer"),
+				Order: 2,
+				Packages: []Package{
+					{Path: LayerPath("app/service"), Level: 0},
+					{Path: LayerPath("app/usecase"), Level: 1},
+				},
+			},
+			{
+				Name:  LayerName("Presentation layer"),
+				Order: 3,
+				Packages: []Package{
+					{Path: LayerPath("api"), Level: 0},
+					{Path: LayerPath("cli"), Level: 0},
+				},
+			},
+			{
+				Name:  LayerName("Infra layer"),
+				Order: 4,
+				Packages: []Package{
+					{Path: LayerPath("infra/database"), Level: 0},
+					{Path: LayerPath("infra/cache"), Level: 0},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		targetPackage Package
+		expectedDeps  []LayerPath
+		expectNoDeps  bool
+	}{
+		{
+			name:          "domain entity - no dependencies",
+			targetPackage: Package{Path: LayerPath("domain/entity"), Level: 0},
+			expectedDeps:  []LayerPath{},
+			expectNoDeps:  true,
+		},
+		{
+			name:          "domain valueobject - depends on entity",
+			targetPackage: Package{Path: LayerPath("domain/valueobject"), Level: 0},
+			expectedDeps:  []LayerPath{LayerPath("domain/entity")},
+			expectNoDeps:  false,
+		},
+		{
+			name:          "domain service - depends on entity and valueobject",
+			targetPackage: Package{Path: LayerPath("domain/service"), Level: 1},
+			expectedDeps: []LayerPath{
+				LayerPath("domain/entity"),
+				LayerPath("domain/valueobject"),
+			},
+		},
+		{
+			name:          "app service - depends on domain layer",
+			targetPackage: Package{Path: LayerPath("app/service"), Level: 0},
+			expectedDeps: []LayerPath{
+				LayerPath("domain/entity"),
+				LayerPath("domain/valueobject"),
+				LayerPath("domain/service"),
+			},
+		},
+		{
+			name:          "app usecase - depends on domain layer and app service",
+			targetPackage: Package{Path: LayerPath("app/usecase"), Level: 1},
+			expectedDeps: []LayerPath{
+				LayerPath("domain/entity"),
+				LayerPath("domain/valueobject"),
+				LayerPath("domain/service"),
+				LayerPath("app/service"),
+			},
+		},
+		{
+			name:          "api - depends on domain and app layers",
+			targetPackage: Package{Path: LayerPath("api"), Level: 0},
+			expectedDeps: []LayerPath{
+				LayerPath("domain/entity"),
+				LayerPath("domain/valueobject"),
+				LayerPath("domain/service"),
+				LayerPath("app/service"),
+				LayerPath("app/usecase"),
+			},
+		},
+		{
+			name:          "infra database - depends on all upper layers",
+			targetPackage: Package{Path: LayerPath("infra/database"), Level: 0},
+			expectedDeps: []LayerPath{
+				LayerPath("domain/entity"),
+				LayerPath("domain/valueobject"),
+				LayerPath("domain/service"),
+				LayerPath("app/service"),
+				LayerPath("app/usecase"),
+				LayerPath("api"),
+				LayerPath("cli"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := config.GetDependenciesForPackage(tt.targetPackage)
+
+			if tt.expectNoDeps {
+				assert.Empty(t, result, "Expected no dependencies")
+				return
+			}
+
+			assert.Len(t, result, len(tt.expectedDeps), "Dependency count mismatch")
+
+			// Convert to sets for comparison (order shouldn't matter in dependencies)
+			resultSet := make(map[LayerPath]bool)
+			for _, dep := range result {
+				resultSet[dep] = true
+			}
+
+			expectedSet := make(map[LayerPath]bool)
+			for _, dep := range tt.expectedDeps {
+				expectedSet[dep] = true
+			}
+
+			for expectedDep := range expectedSet {
+				assert.True(t, resultSet[expectedDep], "Missing expected dependency: %s", expectedDep)
+			}
+
+			for actualDep := range resultSet {
+				assert.True(t, expectedSet[actualDep], "Unexpected dependency: %s", actualDep)
+			}
+		})
+	}
+}
+
+func TestGetAllPackages(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{
+				Name:  LayerName("Domain layer"),
+				Order: 1,
+				Packages: []Package{
+					{Path: LayerPath("domain/entity"), Level: 0},
+					{Path: LayerPath("domain/service"), Level: 1},
+				},
+			},
+			{
+				Name:  LayerName("Application layer"),
+				Order: 2,
+				Packages: []Package{
+					{Path: LayerPath("app/usecase"), Level: 0},
+				},
+			},
+		},
+	}
+
+	packages := config.GetAllPackages()
+
+	expected := []Package{
+		{Path: LayerPath("domain/entity"), Level: 0},
+		{Path: LayerPath("domain/service"), Level: 1},
+		{Path: LayerPath("app/usecase"), Level: 0},
+	}
+
+	assert.Len(t, packages, len(expected))
+
+	// Convert to sets for comparison
+	actualSet := make(map[LayerPath]Package)
+	for _, pkg := range packages {
+		actualSet[pkg.Path] = pkg
+	}
+
+	for _, expectedPkg := range expected {
+		actualPkg, exists := actualSet[expectedPkg.Path]
+		assert.True(t, exists, "Missing package: %s", expectedPkg.Path)
+		assert.Equal(t, expectedPkg.Level, actualPkg.Level, "Level mismatch for package: %s", expectedPkg.Path)
+	}
+}
+
+func TestGetPackagesByLayer(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{
+				Name:  LayerName("Domain layer"),
+				Order: 1,
+				Packages: []Package{
+					{Path: LayerPath("domain/entity"), Level: 0},
+					{Path: LayerPath("domain/service"), Level: 1},
+				},
+			},
+			{
+				Name:  LayerName("Application layer"),
+				Order: 2,
+				Packages: []Package{
+					{Path: LayerPath("app/usecase"), Level: 0},
+				},
+			},
+		},
+	}
+
+	// Test existing layer
+	domainPackages := config.GetPackagesByLayer(LayerName("Domain layer"))
+	expected := []Package{
+		{Path: LayerPath("domain/entity"), Level: 0},
+		{Path: LayerPath("domain/service"), Level: 1},
+	}
+
+	assert.Len(t, domainPackages, len(expected))
+	for i, expectedPkg := range expected {
+		assert.Equal(t, expectedPkg.Path, domainPackages[i].Path)
+		assert.Equal(t, expectedPkg.Level, domainPackages[i].Level)
+	}
+
+	// Test non-existing layer
+	nonExistentPackages := config.GetPackagesByLayer(LayerName("Non-existent layer"))
+	assert.Nil(t, nonExistentPackages)
+}
+
+func TestGenerateDependencyFiles(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{
+				Name:  LayerName("Domain layer"),
+				Order: 1,
+				Packages: []Package{
+					{Path: LayerPath("domain/entity"), Level: 0},
+					{Path: LayerPath("domain/service"), Level: 1},
+				},
+			},
+			{
+				Name:  LayerName("Application layer"),
+				Order: 2,
+				Packages: []Package{
+					{Path: LayerPath("app/usecase"), Level: 0},
+				},
+			},
+		},
+	}
+
+	output := NewMemoryOutput()
+	generator := NewGenerator()
+	generator.Output = output
+	generator.ModuleName = ModuleName("github.com/test/project")
+	err := generator.GenerateDependencyFiles("project", config)
+	require.NoError(t, err)
+
+	// Check that the correct files were generated
+	assert.Equal(t, []string{
+		"app/usecase/dependency.gen.go",
+		"domain/entity/dependency.gen.go",
+		"domain/service/dependency.gen.go",
+	}, output.Names())
+
+	for name, content := range output {
+		contentStr := string(content)
+		assert.Contains(t, contentStr, "// Code generated by go-package-dependency. DO NOT EDIT.")
+
+		// Extract package name from path and verify
+		parts := strings.Split(name, "/")
+		packageName := parts[len(parts)-2] // Get directory name
+		assert.Contains(t, contentStr, "package "+packageName)
+	}
+
+	// Verify specific content for domain/service (should have dependencies)
+	contentStr := string(output["domain/service/dependency.gen.go"])
+	assert.Contains(t, contentStr, `_ "github.com/test/project/domain/entity"`)
+
+	// Verify specific content for app/usecase (should have dependencies from domain layer)
+	contentStr = string(output["app/usecase/dependency.gen.go"])
+	assert.Contains(t, contentStr, `_ "github.com/test/project/domain/entity"`)
+	assert.Contains(t, contentStr, `_ "github.com/test/project/domain/service"`)
+
+	// Verify specific content for domain/entity (should have no dependencies)
+	contentStr = string(output["domain/entity/dependency.gen.go"])
+	assert.NotContains(t, contentStr, "import (")
+}
+
+func TestGenerateDependencyFiles_OSOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module github.com/test/project\n\ngo 1.21\n"), 0644)
+	require.NoError(t, err)
+
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Application layer"), Order: 2, Packages: []Package{{Path: LayerPath("app/usecase")}}},
+		},
+	}
+
+	generator := NewGenerator()
+	require.NoError(t, generator.GenerateDependencyFiles(tmpDir, config))
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "app/usecase", GeneratedFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `_ "github.com/test/project/domain/entity"`)
+	assert.FileExists(t, filepath.Join(tmpDir, "domain/entity", GeneratedFileName))
+}
+
+func TestGenerateTestDependencyFileContent(t *testing.T) {
+	generator := NewGenerator()
+	result := generator.GenerateTestDependencyFileContent(
+		LayerPath("domain/entity"),
+		[]LayerPath{LayerPath("infra/fake")},
+		ModuleName("github.com/example/project"),
+	)
+
+	expected := `// Code generated by go-package-dependency. DO NOT EDIT.
+
+package entity_test
+
+import (
+_ "github.com/example/project/domain/entity"
+_ "github.com/example/project/infra/fake"
+)
+`
+	assert.Equal(t, expected, result)
+}
+
+func TestGenerateDependencyFiles_TestFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	err := os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module github.com/test/project\n\ngo 1.21\n"), 0644)
+	require.NoError(t, err)
+
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Infra layer"), Order: 2, Packages: []Package{{Path: LayerPath("infra/fake")}}},
+		},
+		TestMode: TestModeCustom,
+		TestImports: []TestImportRule{
+			{Scope: RuleScope{Layer: LayerName("Domain layer"), Order: 1}, Pattern: PackagePattern("infra/fake")},
+		},
+	}
+
+	generator := NewGenerator()
+	require.NoError(t, generator.GenerateDependencyFiles(tmpDir, config))
+	assert.NoFileExists(t, filepath.Join(tmpDir, "domain/entity", GeneratedTestFileName))
+
+	generator.GenerateTestFiles = true
+	require.NoError(t, generator.GenerateDependencyFiles(tmpDir, config))
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "domain/entity", GeneratedTestFileName))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "package entity_test")
+	assert.Contains(t, string(content), `_ "github.com/test/project/domain/entity"`)
+	assert.Contains(t, string(content), `_ "github.com/test/project/infra/fake"`)
+
+	content, err = os.ReadFile(filepath.Join(tmpDir, "domain/entity", GeneratedFileName))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "infra/fake")
+}
+
+func TestGenerator_Run(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Application layer"), Order: 2, Packages: []Package{{Path: LayerPath("app/usecase")}}},
+		},
+	}
+
+	output := NewMemoryOutput()
+	generator := NewGenerator()
+	generator.Output = output
+	generator.ModuleName = ModuleName("github.com/test/project")
+
+	result, err := generator.Run(context.Background(), ".", config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"domain/entity/dependency.gen.go", "app/usecase/dependency.gen.go"}, result.Created)
+	assert.Equal(t, "2 created, 0 updated, 0 unchanged, 0 deleted", result.String())
+
+	// Running again changes nothing
+	result, err = generator.Run(context.Background(), ".", config)
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Equal(t, []string{"domain/entity/dependency.gen.go", "app/usecase/dependency.gen.go"}, result.Unchanged)
+
+	// A stale generated file is removed, a hand-written one with the same name is kept
+	output["infra/old/dependency.gen.go"] = []byte(generatedHeader + "\n\npackage old\n")
+	output["infra/manual/dependency.gen.go"] = []byte("package manual\n")
+	output["app/usecase/dependency.gen.go"] = []byte(generatedHeader + "\n\npackage usecase\n")
+
+	result, err = generator.Run(context.Background(), ".", config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app/usecase/dependency.gen.go"}, result.Updated)
+	assert.Equal(t, []string{"domain/entity/dependency.gen.go"}, result.Unchanged)
+	assert.Equal(t, []string{"infra/old/dependency.gen.go"}, result.Deleted)
+	assert.Equal(t, []string{
+		"app/usecase/dependency.gen.go",
+		"domain/entity/dependency.gen.go",
+		"infra/manual/dependency.gen.go",
+	}, output.Names())
+	assert.Contains(t, string(output["app/usecase/dependency.gen.go"]), "domain/entity")
+}
+
+func TestGenerator_Run_SkipsUnchangedFilesOnDisk(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module github.com/test/project\n"), 0644))
+
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+		},
+	}
+
+	generator := NewGenerator()
+	_, err := generator.Run(context.Background(), tmpDir, config)
+	require.NoError(t, err)
+
+	generatedPath := filepath.Join(tmpDir, "domain/entity", GeneratedFileName)
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(generatedPath, past, past))
+
+	result, err := generator.Run(context.Background(), tmpDir, config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"domain/entity/dependency.gen.go"}, result.Unchanged)
+
+	info, err := os.Stat(generatedPath)
+	require.NoError(t, err)
+	assert.Equal(t, past, info.ModTime())
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Join(tmpDir, "domain/entity"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestGenerator_Run_ForeignFiles(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Application layer"), Order: 2, Packages: []Package{{Path: LayerPath("app/usecase")}}},
+		},
+	}
+
+	output := NewMemoryOutput()
+	output["app/usecase/dependency.gen.go"] = []byte("package usecase\n\nvar handWritten = true\n")
+
+	generator := NewGenerator()
+	generator.Output = output
+	generator.ModuleName = ModuleName("github.com/test/project")
+
+	_, err := generator.Run(context.Background(), "project", config)
+
+	var foreignErr ForeignFileError
+	require.ErrorAs(t, err, &foreignErr)
+	assert.Equal(t, filepath.Join("project", "app/usecase/dependency.gen.go"), foreignErr.Path)
+	assert.Equal(t, []string{"app/usecase/dependency.gen.go"}, output.Names())
+
+	generator.Force = true
+	result, err := generator.Run(context.Background(), "project", config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"app/usecase/dependency.gen.go"}, result.Updated)
+	assert.Contains(t, string(output["app/usecase/dependency.gen.go"]), generatedHeader)
+}
+
+func TestGenerator_Run_FileName(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+		},
+	}
+
+	output := NewMemoryOutput()
+	output["domain/entity/dependency.gen.go"] = []byte("package entity\n")
+
+	generator := NewGenerator()
+	generator.Output = output
+	generator.ModuleName = ModuleName("github.com/test/project")
+	generator.FileName = "deps.gen.go"
+	generator.GenerateTestFiles = true
+
+	result, err := generator.Run(context.Background(), ".", config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"domain/entity/deps.gen.go", "domain/entity/deps_gen_test.go"}, result.Created)
+	assert.Equal(t, []string{
+		"domain/entity/dependency.gen.go",
+		"domain/entity/deps.gen.go",
+		"domain/entity/deps_gen_test.go",
+	}, output.Names())
+}
+
+func TestGenerator_Run_InvalidFileName(t *testing.T) {
+	tests := []string{
+		"internal/deps.gen.go",
+		"deps.gen.txt",
+		".go",
+		"deps_test.go",
+		"_deps.go",
+		".deps.go",
+	}
+
+	for _, name := range tests {
+		t.Run(name, func(t *testing.T) {
+			generator := NewGenerator()
+			generator.Output = NewMemoryOutput()
+			generator.ModuleName = ModuleName("github.com/test/project")
+			generator.FileName = name
+
+			_, err := generator.Run(context.Background(), ".", &DependencyConfig{})
+			var nameErr InvalidFileNameError
+			assert.ErrorAs(t, err, &nameErr)
+		})
+	}
+}
+
+func TestTestFileName(t *testing.T) {
+	assert.Equal(t, GeneratedTestFileName, testFileName(GeneratedFileName))
+	assert.Equal(t, "deps_test.go", testFileName("deps.go"))
+	assert.Equal(t, "zz_deps_gen_test.go", testFileName("zz_deps.gen.go"))
+}
+
+func TestGenerator_Run_PathEscape(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "go.mod"), []byte("module github.com/test/project\n"), 0644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "infra")))
+
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Infra layer"), Order: 2, Packages: []Package{{Path: LayerPath("infra/db")}}},
+		},
+	}
+
+	_, err := NewGenerator().Run(context.Background(), root, config)
+	var escapeErr PathEscapeError
+	require.ErrorAs(t, err, &escapeErr)
+
+	// Nothing is written outside, and the rest of the run is rolled back
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.NoDirExists(t, filepath.Join(root, "domain"))
+}
+
+func TestGenerator_Run_InvalidPackagePaths(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{
+				{Path: LayerPath("/etc/x")},
+				{Path: LayerPath("../outside")},
+				{Path: LayerPath(`domain\entity`)},
+			}},
+		},
+	}
+
+	output := NewMemoryOutput()
+	generator := NewGenerator()
+	generator.Output = output
+	generator.ModuleName = ModuleName("github.com/test/project")
+
+	_, err := generator.Run(context.Background(), ".", config)
+
+	var generateErr GenerateError
+	require.ErrorAs(t, err, &generateErr)
+	require.Len(t, generateErr.Errors, 3)
+	assert.ErrorAs(t, generateErr.Errors[0], &AbsolutePathError{})
+	assert.ErrorAs(t, generateErr.Errors[1], &PathTraversalError{})
+	assert.ErrorAs(t, generateErr.Errors[2], &InvalidPathElementError{})
+	assert.Empty(t, output)
+}
+
+func TestGenerator_Run_NormalizesPackagePaths(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("./domain/entity/")}}},
+			{Name: LayerName("Application layer"), Order: 2, Packages: []Package{{Path: LayerPath(" app/usecase")}}},
+		},
+	}
+
+	output := NewMemoryOutput()
+	generator := NewGenerator()
+	generator.Output = output
+	generator.ModuleName = ModuleName("github.com/test/project")
+
+	result, err := generator.Run(context.Background(), t.TempDir(), config)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"domain/entity/dependency.gen.go", "app/usecase/dependency.gen.go"}, result.Created)
+	assert.Contains(t, string(output["app/usecase/dependency.gen.go"]), `_ "github.com/test/project/domain/entity"`)
+	// The caller's config keeps its paths
+	assert.Equal(t, LayerPath("./domain/entity/"), config.Layers[0].Packages[0].Path)
+}
@@ -0,0 +1,47 @@
+package dependency
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GitPrefix starts a source that reads DEPENDENCY.md from git, like
+// git:main:DEPENDENCY.md
+const GitPrefix = "git:"
+
+// ParseSource reads a DEPENDENCY.md from a path, or from a git revision
+// written as git:<ref>:<path>
+func ParseSource(source string) (*Config, error) {
+	spec, ok := strings.CutPrefix(source, GitPrefix)
+	if !ok {
+		return NewParser().ParseDependencyFile(source)
+	}
+
+	ref, path, ok := strings.Cut(spec, ":")
+	if !ok || ref == "" || path == "" {
+		return nil, fmt.Errorf("want git:<ref>:<path>, got %q", source)
+	}
+	content, err := gitShow("", ref, path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(bytes.NewReader(content))
+}
+
+// gitShow returns the content of path at ref in the repository of dir, the
+// current directory when empty. git resolves the path from the repository
+// root unless it starts with ./
+func gitShow(dir, ref, path string) ([]byte, error) {
+	var stderr bytes.Buffer
+	// --end-of-options keeps a ref like --output=file from being read as an option
+	cmd := exec.Command("git", "show", "--end-of-options", ref+":"+path)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	content, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show %s:%s: %w: %s", ref, path, err, strings.TrimSpace(stderr.String()))
+	}
+	return content, nil
+}
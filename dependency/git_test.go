@@ -0,0 +1,81 @@
+package dependency
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitRepository creates a repository with one commit of the given files
+func gitRepository(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	return dir
+}
+
+func TestGitShow(t *testing.T) {
+	dir := gitRepository(t, map[string]string{
+		"DEPENDENCY.md": "## Layers\n\n1. Domain layer\n",
+		"EMPTY.md":      "",
+	})
+
+	content, err := gitShow(dir, "HEAD", "DEPENDENCY.md")
+	require.NoError(t, err)
+	assert.Equal(t, "## Layers\n\n1. Domain layer\n", string(content))
+
+	// Refs that look like options are read as revisions
+	injected := filepath.Join(t.TempDir(), "injected")
+	_, err = gitShow(dir, "--output="+injected, "x")
+	assert.ErrorContains(t, err, "git show --output="+injected+":x: exit status 128")
+	assert.NoFileExists(t, injected)
+
+	// An empty file is a valid config
+	content, err = gitShow(dir, "HEAD", "EMPTY.md")
+	require.NoError(t, err)
+	assert.Empty(t, content)
+
+	_, err = gitShow(dir, "HEAD", "MISSING.md")
+	assert.ErrorContains(t, err, "git show HEAD:MISSING.md: exit status 128")
+}
+
+func TestParseSource(t *testing.T) {
+	dir := gitRepository(t, map[string]string{
+		"DEPENDENCY.md": "## Layers\n\n1. Domain layer\n",
+	})
+	t.Chdir(dir)
+	require.NoError(t, os.WriteFile("DEPENDENCY.md", []byte("## Layers\n\n1. Domain layer\n2. Infra layer\n"), 0644))
+
+	committed, err := ParseSource("git:HEAD:DEPENDENCY.md")
+	require.NoError(t, err)
+	assert.Len(t, committed.Layers, 1)
+
+	working, err := ParseSource("DEPENDENCY.md")
+	require.NoError(t, err)
+	assert.Len(t, working.Layers, 2)
+
+	for _, source := range []string{"git:HEAD", "git::DEPENDENCY.md", "git:HEAD:"} {
+		_, err := ParseSource(source)
+		assert.EqualError(t, err, `want git:<ref>:<path>, got "`+source+`"`)
+	}
+}
@@ -0,0 +1,136 @@
+package dependency
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// ImportGraph is what the production files of the listed packages actually
+// import from each other, and how many exported types they declare
+type ImportGraph struct {
+	Imports    map[LayerPath][]LayerPath // Listed packages each package imports, sorted
+	Types      map[LayerPath]int         // Exported types of each package
+	Interfaces map[LayerPath]int         // Exported interface types of each package
+}
+
+// ImportedBy returns the listed packages that import path, sorted
+func (g *ImportGraph) ImportedBy(path LayerPath) []LayerPath {
+	var importers []LayerPath
+	for importer, imports := range g.Imports {
+		if slices.Contains(imports, path) {
+			importers = append(importers, importer)
+		}
+	}
+	slices.Sort(importers)
+	return importers
+}
+
+// Imported reports whether importer imports imported
+func (g *ImportGraph) Imported(importer, imported LayerPath) bool {
+	return slices.Contains(g.Imports[importer], imported)
+}
+
+// BuildImportGraph reads the imports and exported types of every listed
+// package. Test files and imports of unlisted packages are left out.
+func (a *Analyzer) BuildImportGraph(ctx context.Context, baseDir string, config *DependencyConfig) (*ImportGraph, error) {
+	moduleName, err := NewParser().GetModuleName(filepath.Join(baseDir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+
+	config = config.indexed()
+
+	files, err := listSourceFiles(baseDir, config)
+	if err != nil {
+		return nil, err
+	}
+
+	type fileSummary struct {
+		imports    []LayerPath
+		types      int
+		interfaces int
+		test       bool
+	}
+
+	summaries := make([]fileSummary, len(files))
+	errs := forEach(ctx, a.Workers, len(files), true, func(i int) error {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, files[i].path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return SourceParseError{Path: files[i].path, Err: err}
+		}
+
+		summary := &summaries[i]
+		if ClassifyFile(filepath.Base(files[i].path), file.Name.Name) != FileKindProduction {
+			summary.test = true
+			return nil
+		}
+		for _, spec := range file.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			if importedPath, ok := moduleName.RelativePath(importPath); ok {
+				if imported, ok := config.FindPackage(importedPath); ok && imported.Path != files[i].pkg.Path {
+					summary.imports = append(summary.imports, imported.Path)
+				}
+			}
+		}
+		summary.types, summary.interfaces = countExportedTypes(file)
+		return nil
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	graph := &ImportGraph{
+		Imports:    make(map[LayerPath][]LayerPath),
+		Types:      make(map[LayerPath]int),
+		Interfaces: make(map[LayerPath]int),
+	}
+	for i, summary := range summaries {
+		if summary.test {
+			continue
+		}
+		path := files[i].pkg.Path
+		graph.Imports[path] = append(graph.Imports[path], summary.imports...)
+		graph.Types[path] += summary.types
+		graph.Interfaces[path] += summary.interfaces
+	}
+	for path, imports := range graph.Imports {
+		slices.Sort(imports)
+		graph.Imports[path] = slices.Compact(imports)
+	}
+
+	return graph, nil
+}
+
+// countExportedTypes counts the exported type declarations of a file and
+// how many of them are interfaces
+func countExportedTypes(file *ast.File) (types int, interfaces int) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.TypeSpec)
+			if !spec.Name.IsExported() {
+				continue
+			}
+			types++
+			if _, ok := spec.Type.(*ast.InterfaceType); ok {
+				interfaces++
+			}
+		}
+	}
+	return types, interfaces
+}
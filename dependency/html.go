@@ -0,0 +1,87 @@
+package dependency
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"io"
+)
+
+//go:embed assets/report.html
+var htmlReportTemplate string
+
+// htmlReport renders the report page, which draws everything from the
+// embedded data payload with inline scripts and styles
+var htmlReport = template.Must(template.New("report.html").Parse(htmlReportTemplate))
+
+// HTMLReport is the data payload of the HTML architecture report
+type HTMLReport struct {
+	Title      string      `json:"title"`
+	Layers     []HTMLLayer `json:"layers"` // Shared packages first, then the layers in order
+	Violations []Finding   `json:"violations"`
+	Metrics    *Metrics    `json:"metrics"`
+}
+
+// HTMLLayer is a layer of the report, or the shared packages when Name is empty
+type HTMLLayer struct {
+	Name     LayerName     `json:"name"`
+	Order    int           `json:"order"`
+	Isolated bool          `json:"isolated"`
+	Packages []HTMLPackage `json:"packages"`
+}
+
+// HTMLPackage is a listed package with what it may and does import
+type HTMLPackage struct {
+	Path    LayerPath   `json:"path"`
+	Level   int         `json:"level"`
+	Allowed []LayerPath `json:"allowed"`
+	Imports []LayerPath `json:"imports"`
+}
+
+// NewHTMLReport collects the data of the HTML report from the configuration,
+// the import graph and the findings of the import analysis
+func NewHTMLReport(title string, config *DependencyConfig, graph *ImportGraph, violations []Finding) *HTMLReport {
+	report := &HTMLReport{
+		Title:      title,
+		Layers:     []HTMLLayer{},
+		Violations: violations,
+		Metrics:    ComputeMetrics(config, graph),
+	}
+	if report.Violations == nil {
+		report.Violations = []Finding{}
+	}
+
+	newLayer := func(name LayerName, order int, isolated bool, packages []Package) HTMLLayer {
+		layer := HTMLLayer{Name: name, Order: order, Isolated: isolated, Packages: []HTMLPackage{}}
+		for _, pkg := range packages {
+			layer.Packages = append(layer.Packages, HTMLPackage{
+				Path:    pkg.Path,
+				Level:   pkg.Level,
+				Allowed: append([]LayerPath{}, config.GetDependenciesForPackage(pkg)...),
+				Imports: append([]LayerPath{}, graph.Imports[pkg.Path]...),
+			})
+		}
+		return layer
+	}
+	if len(config.Shared) > 0 {
+		report.Layers = append(report.Layers, newLayer("", 0, false, config.Shared))
+	}
+	for _, layer := range config.Layers {
+		report.Layers = append(report.Layers, newLayer(layer.Name, layer.Order, layer.Isolated, layer.Packages))
+	}
+
+	return report
+}
+
+// WriteHTML writes the report as a single HTML file that works offline
+func (r *HTMLReport) WriteHTML(w io.Writer) error {
+	// json.Marshal escapes <, > and &, so the payload cannot close the script element
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return htmlReport.Execute(w, struct {
+		Title string
+		Data  template.JS
+	}{Title: r.Title, Data: template.JS(data)})
+}
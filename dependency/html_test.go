@@ -0,0 +1,53 @@
+package dependency
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTMLReport_Golden(t *testing.T) {
+	tmpDir, config := metricsProject(t)
+	graph, err := NewAnalyzer().BuildImportGraph(context.Background(), tmpDir, config)
+	require.NoError(t, err)
+	violations, err := NewAnalyzer().AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+	for i := range violations {
+		violations[i].File, err = filepath.Rel(tmpDir, violations[i].File)
+		require.NoError(t, err)
+	}
+
+	report := NewHTMLReport("example.com/app", config, graph, ViolationFindings(violations))
+	data, err := marshalJSON(report)
+	require.NoError(t, err)
+
+	golden := filepath.Join(reportGoldenDir, "html.json")
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		require.NoError(t, os.WriteFile(golden, data, 0644))
+	}
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(data))
+}
+
+func TestHTMLReport_WriteHTML(t *testing.T) {
+	report := NewHTMLReport("example.com/<app>", &DependencyConfig{}, &ImportGraph{}, []Finding{
+		{RuleID: RuleLayerViolation, Severity: SeverityError, Message: "</script><script>alert(1)</script>"},
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteHTML(&buf))
+	page := buf.String()
+
+	assert.Contains(t, page, "<title>example.com/&lt;app&gt; architecture</title>")
+	assert.Contains(t, page, `\u003c/script\u003e\u003cscript\u003ealert(1)`)
+	assert.Equal(t, 2, strings.Count(page, "</script>"))
+	assert.NotContains(t, page, " src=")
+	assert.NotContains(t, page, `href="http`)
+}
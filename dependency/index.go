@@ -0,0 +1,144 @@
+package dependency
+
+import (
+	"path"
+	"slices"
+)
+
+// packageIndex answers package queries without scanning every layer.
+// It is only attached to private copies made by indexed, which are never
+// changed afterwards, so it cannot go stale.
+type packageIndex struct {
+	// shared maps a shared package path to its position in Shared
+	shared map[LayerPath]int
+	// layered maps a layer package path to its layer and its position there
+	layered map[LayerPath]layerPosition
+	// upper holds, per layer, the shared packages followed by the packages
+	// of every layer with a lower order
+	upper [][]LayerPath
+}
+
+type layerPosition struct {
+	layer    int
+	position int
+}
+
+// indexed returns a snapshot of the config with a package index. The
+// generator and analyzer query the snapshot, so the caller may keep
+// changing, or sharing, its own config.
+func (dc *DependencyConfig) indexed() *DependencyConfig {
+	copied := *dc
+	copied.Shared = slices.Clone(dc.Shared)
+	copied.Layers = make([]Layer, len(dc.Layers))
+	for i, layer := range dc.Layers {
+		layer.Packages = slices.Clone(layer.Packages)
+		copied.Layers[i] = layer
+	}
+	copied.index = newPackageIndex(&copied)
+	return &copied
+}
+
+// newPackageIndex precomputes the lookups used by the query methods
+func newPackageIndex(dc *DependencyConfig) *packageIndex {
+	index := &packageIndex{
+		shared:  make(map[LayerPath]int, len(dc.Shared)),
+		layered: map[LayerPath]layerPosition{},
+		upper:   make([][]LayerPath, len(dc.Layers)),
+	}
+
+	for i, pkg := range dc.Shared {
+		if _, ok := index.shared[pkg.Path]; !ok {
+			index.shared[pkg.Path] = i
+		}
+	}
+	for i, layer := range dc.Layers {
+		for j, pkg := range layer.Packages {
+			if _, ok := index.layered[pkg.Path]; !ok {
+				index.layered[pkg.Path] = layerPosition{layer: i, position: j}
+			}
+		}
+	}
+
+	for i, layer := range dc.Layers {
+		var upper []LayerPath
+		for _, pkg := range dc.Shared {
+			upper = append(upper, pkg.Path)
+		}
+		for _, other := range dc.Layers {
+			if other.Order < layer.Order {
+				for _, pkg := range other.Packages {
+					upper = append(upper, pkg.Path)
+				}
+			}
+		}
+		index.upper[i] = upper
+	}
+
+	return index
+}
+
+// lookup returns the first listed package with exactly the given path
+func (index *packageIndex) lookup(dc *DependencyConfig, p LayerPath) (Package, bool) {
+	if i, ok := index.shared[p]; ok {
+		return dc.Shared[i], true
+	}
+	if position, ok := index.layered[p]; ok {
+		return dc.Layers[position.layer].Packages[position.position], true
+	}
+	return Package{}, false
+}
+
+// findPackage returns the package itself or its closest listed ancestor
+func (index *packageIndex) findPackage(dc *DependencyConfig, p LayerPath) (Package, bool) {
+	for current := path.Clean(string(p)); ; current = path.Dir(current) {
+		if pkg, ok := index.lookup(dc, LayerPath(current)); ok {
+			return pkg, true
+		}
+		if current == "." || current == "/" || path.Dir(current) == current {
+			return Package{}, false
+		}
+	}
+}
+
+// isDependencyAllowed answers IsDependencyAllowed without building the
+// dependency list
+func (index *packageIndex) isDependencyAllowed(dc *DependencyConfig, importer Package, imported Package) bool {
+	if importer.Path == imported.Path {
+		return true
+	}
+
+	if importerPosition, ok := index.shared[importer.Path]; ok {
+		importedPosition, ok := index.shared[imported.Path]
+		return ok && isSiblingDependency(dc.Shared[importedPosition].Level, importedPosition, importer.Level, importerPosition)
+	}
+
+	importerPosition, ok := index.layered[importer.Path]
+	if !ok {
+		return false
+	}
+	if _, ok := index.shared[imported.Path]; ok {
+		return true
+	}
+	importedPosition, ok := index.layered[imported.Path]
+	if !ok {
+		return false
+	}
+
+	importerLayer := dc.Layers[importerPosition.layer]
+	importedLayer := dc.Layers[importedPosition.layer]
+	if importedLayer.Order < importerLayer.Order {
+		return true
+	}
+	if importedPosition.layer != importerPosition.layer || importerLayer.Isolated {
+		return false
+	}
+
+	importedLevel := importerLayer.Packages[importedPosition.position].Level
+	return isSiblingDependency(importedLevel, importedPosition.position, importer.Level, importerPosition.position)
+}
+
+// isSiblingDependency is the rule of getSiblingDependencies: a package may
+// depend on packages at a higher level, or at the same level listed before it
+func isSiblingDependency(level int, position int, targetLevel int, targetPosition int) bool {
+	return level < targetLevel || level == targetLevel && position < targetPosition
+}
@@ -0,0 +1,203 @@
+package dependency
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syntheticConfig builds a config with the given number of packages spread
+// over layers, with nested levels and a few shared packages
+func syntheticConfig(packages int, layers int, seed int64) *DependencyConfig {
+	random := rand.New(rand.NewSource(seed))
+
+	config := &DependencyConfig{}
+	for i := 0; i < 5; i++ {
+		config.Shared = append(config.Shared, Package{Path: LayerPath(fmt.Sprintf("pkg/shared%d", i)), Level: random.Intn(2)})
+	}
+
+	for i := 0; i < layers; i++ {
+		config.Layers = append(config.Layers, Layer{
+			Name:     LayerName(fmt.Sprintf("Layer %d", i)),
+			Order:    i/2 + 1,
+			Isolated: i%3 == 2,
+		})
+	}
+	for i := 0; i < packages; i++ {
+		layer := &config.Layers[random.Intn(layers)]
+		layer.Packages = append(layer.Packages, Package{
+			Path:  LayerPath(fmt.Sprintf("layer%d/pkg%d", random.Intn(layers), i)),
+			Level: random.Intn(3),
+		})
+	}
+
+	return config
+}
+
+// isolatedConfig builds a config with the given number of packages in
+// isolated layers, so each generated file only imports the shared packages
+func isolatedConfig(packages int, layers int) *DependencyConfig {
+	config := syntheticConfig(packages, layers, 1)
+	for i := range config.Layers {
+		config.Layers[i].Order = 1
+		config.Layers[i].Isolated = true
+	}
+	return config
+}
+
+func TestIndexed_MatchesScanning(t *testing.T) {
+	for seed := int64(0); seed < 5; seed++ {
+		scanned := syntheticConfig(200, 6, seed)
+		indexed := syntheticConfig(200, 6, seed).indexed()
+		require.NotNil(t, indexed.index)
+
+		all := scanned.GetAllPackages()
+		probes := append([]Package{{Path: LayerPath("unlisted/pkg")}}, all...)
+
+		for _, importer := range probes {
+			assert.Equal(t, scanned.GetDependenciesForPackage(importer), indexed.GetDependenciesForPackage(importer), importer.Path)
+			assert.Equal(t, scanned.FindLayer(importer.Path), indexed.FindLayer(importer.Path), importer.Path)
+			assert.Equal(t, scanned.IsShared(importer.Path), indexed.IsShared(importer.Path), importer.Path)
+
+			scannedPackage, scannedOK := scanned.Lookup(importer.Path)
+			indexedPackage, indexedOK := indexed.Lookup(importer.Path)
+			assert.Equal(t, scannedOK, indexedOK)
+			assert.Equal(t, scannedPackage, indexedPackage)
+
+			sub := importer.Path + "/internal/x"
+			scannedPackage, scannedOK = scanned.FindPackage(sub)
+			indexedPackage, indexedOK = indexed.FindPackage(sub)
+			assert.Equal(t, scannedOK, indexedOK, sub)
+			assert.Equal(t, scannedPackage, indexedPackage, sub)
+
+			for _, imported := range probes[:40] {
+				assert.Equal(t,
+					scanned.IsDependencyAllowed(importer, imported),
+					indexed.IsDependencyAllowed(importer, imported),
+					"%s -> %s", importer.Path, imported.Path)
+			}
+		}
+	}
+}
+
+func TestIndexed_ConfigEditedInPlace(t *testing.T) {
+	config, err := NewParser().ParseDependencyContent(strings.NewReader(`## Layers
+
+1. Domain layer
+2. Application layer
+
+## Packages in layers
+
+1. Domain layer
+  - domain/entity
+2. Application layer
+  - app/usecase
+`))
+	require.NoError(t, err)
+
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), "module example.com/app\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity/entity.go"), "package entity\n\nimport _ \"example.com/app/app/usecase\"\n")
+	writeTestFile(t, filepath.Join(tmpDir, "app/usecase/usecase.go"), "package usecase\n")
+
+	// Analyzing does not attach an index to the caller's config
+	_, err = NewAnalyzer().AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+	assert.Nil(t, config.index)
+
+	// Swapping the orders makes the domain depend on the application
+	config.Layers[0].Order, config.Layers[1].Order = 2, 1
+	entity := Package{Path: LayerPath("domain/entity")}
+	assert.Equal(t, []LayerPath{LayerPath("app/usecase")}, config.GetDependenciesForPackage(entity))
+	assert.True(t, config.IsDependencyAllowed(entity, Package{Path: LayerPath("app/usecase")}))
+
+	violations, err := NewAnalyzer().AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+	assert.Empty(t, violations)
+
+	// Renamed packages are found by their new path only
+	config.Layers[0].Packages[0].Path = LayerPath("domain/model")
+	_, ok := config.Lookup(LayerPath("domain/entity"))
+	assert.False(t, ok)
+	pkg, ok := config.FindPackage(LayerPath("domain/model/user"))
+	assert.True(t, ok)
+	assert.Equal(t, LayerPath("domain/model"), pkg.Path)
+	assert.Equal(t, LayerName("Domain layer"), config.FindLayer(LayerPath("domain/model")).Name)
+}
+
+func TestGetDependenciesForPackage_IndexedResultIsACopy(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/b")}, {Path: LayerPath("domain/a")}}},
+			{Name: LayerName("Application layer"), Order: 2, Packages: []Package{{Path: LayerPath("app/usecase")}}},
+		},
+	}
+	config = config.indexed()
+
+	dependencies := config.GetDependenciesForPackage(Package{Path: LayerPath("app/usecase")})
+	dependencies[0] = LayerPath("changed")
+
+	assert.Equal(t, []LayerPath{LayerPath("domain/b"), LayerPath("domain/a")}, config.GetDependenciesForPackage(Package{Path: LayerPath("app/usecase")}))
+}
+
+func BenchmarkGetDependenciesForPackage(b *testing.B) {
+	for _, indexed := range []bool{false, true} {
+		b.Run(fmt.Sprintf("packages=5000/indexed=%t", indexed), func(b *testing.B) {
+			config := syntheticConfig(5000, 8, 1)
+			if indexed {
+				config = config.indexed()
+			}
+			packages := config.GetAllPackages()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, pkg := range packages {
+					config.GetDependenciesForPackage(pkg)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkIsDependencyAllowed(b *testing.B) {
+	for _, indexed := range []bool{false, true} {
+		b.Run(fmt.Sprintf("packages=5000/indexed=%t", indexed), func(b *testing.B) {
+			config := syntheticConfig(5000, 8, 1)
+			if indexed {
+				config = config.indexed()
+			}
+			packages := config.GetAllPackages()
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				importer := packages[i%len(packages)]
+				imported := packages[(i*7919)%len(packages)]
+				config.IsDependencyAllowed(importer, imported)
+			}
+		})
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	for _, workers := range []int{1, 0} {
+		b.Run(fmt.Sprintf("packages=5000/workers=%d", workers), func(b *testing.B) {
+			config := isolatedConfig(5000, 8)
+
+			for i := 0; i < b.N; i++ {
+				generator := NewGenerator()
+				generator.Output = NewMemoryOutput()
+				generator.ModuleName = ModuleName("example.com/monorepo")
+				generator.Workers = workers
+				if _, err := generator.Run(context.Background(), ".", config); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package dependency
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// MatrixCell tells whether a package may and does import another
+type MatrixCell string
+
+const (
+	CellSelf      MatrixCell = "self"
+	CellForbidden MatrixCell = "forbidden"
+	CellAllowed   MatrixCell = "allowed"   // Allowed, and not imported or the imports were not read
+	CellUsed      MatrixCell = "used"      // Allowed and imported
+	CellViolation MatrixCell = "violation" // Imported although forbidden
+)
+
+// markdownCells are the symbols of the cells in a Markdown table
+var markdownCells = map[MatrixCell]string{
+	CellSelf:      "-",
+	CellForbidden: "",
+	CellAllowed:   "✓",
+	CellUsed:      "●",
+	CellViolation: "✗",
+}
+
+// Matrix is the package × package matrix of allowed dependencies.
+// Cells[i][j] tells whether Packages[i] may and does import Packages[j].
+type Matrix struct {
+	Packages []LayerPath    `json:"packages"`
+	Cells    [][]MatrixCell `json:"cells"`
+}
+
+// NewMatrix computes the matrix from the rules and, when graph is not nil,
+// the imports the packages actually make. Shared packages come first, then
+// the packages of each layer by order and level.
+func NewMatrix(config *DependencyConfig, graph *ImportGraph) *Matrix {
+	type row struct {
+		pkg   Package
+		order int
+	}
+	var rows []row
+	for _, pkg := range config.Shared {
+		rows = append(rows, row{pkg: pkg})
+	}
+	for _, layer := range config.Layers {
+		for _, pkg := range layer.Packages {
+			rows = append(rows, row{pkg: pkg, order: layer.Order})
+		}
+	}
+	slices.SortStableFunc(rows, func(a, b row) int {
+		if a.order != b.order {
+			return a.order - b.order
+		}
+		return a.pkg.Level - b.pkg.Level
+	})
+
+	matrix := &Matrix{Packages: make([]LayerPath, len(rows)), Cells: make([][]MatrixCell, len(rows))}
+	for i, r := range rows {
+		matrix.Packages[i] = r.pkg.Path
+	}
+	for i, r := range rows {
+		allowed := config.GetDependenciesForPackage(r.pkg)
+		cells := make([]MatrixCell, len(rows))
+		for j, imported := range matrix.Packages {
+			isAllowed := slices.Contains(allowed, imported)
+			isUsed := graph != nil && graph.Imported(r.pkg.Path, imported)
+			switch {
+			case i == j:
+				cells[j] = CellSelf
+			case isAllowed && isUsed:
+				cells[j] = CellUsed
+			case isAllowed:
+				cells[j] = CellAllowed
+			case isUsed:
+				cells[j] = CellViolation
+			default:
+				cells[j] = CellForbidden
+			}
+		}
+		matrix.Cells[i] = cells
+	}
+
+	return matrix
+}
+
+// WriteCSV writes the matrix with a header row of imported packages and a
+// first column of importers
+func (m *Matrix) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"importer"}
+	for _, path := range m.Packages {
+		header = append(header, path.String())
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for i, cells := range m.Cells {
+		record := []string{m.Packages[i].String()}
+		for _, cell := range cells {
+			record = append(record, string(cell))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteMarkdown writes the matrix as a Markdown table followed by a legend
+func (m *Matrix) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("| importer \\ imported |")
+	for _, path := range m.Packages {
+		fmt.Fprintf(&b, " %s |", path)
+	}
+	b.WriteString("\n|---|")
+	b.WriteString(strings.Repeat(":-:|", len(m.Packages)))
+	b.WriteString("\n")
+	for i, cells := range m.Cells {
+		fmt.Fprintf(&b, "| %s |", m.Packages[i])
+		for _, cell := range cells {
+			fmt.Fprintf(&b, " %s |", markdownCells[cell])
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "\n%s allowed, %s imported, %s imported although forbidden, empty: forbidden\n",
+		markdownCells[CellAllowed], markdownCells[CellUsed], markdownCells[CellViolation])
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
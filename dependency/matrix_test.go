@@ -0,0 +1,58 @@
+package dependency
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMatrix(t *testing.T) {
+	tmpDir, config := metricsProject(t)
+	graph, err := NewAnalyzer().BuildImportGraph(context.Background(), tmpDir, config)
+	require.NoError(t, err)
+
+	matrix := NewMatrix(config, graph)
+
+	assert.Equal(t, []LayerPath{"pkg/errors", "domain/entity", "domain/valueobject", "app/usecase", "infra/db", "infra/cache"}, matrix.Packages)
+	assert.Equal(t, []MatrixCell{CellUsed, CellSelf, CellViolation, CellForbidden, CellForbidden, CellForbidden}, matrix.Cells[1])
+	assert.Equal(t, []MatrixCell{CellAllowed, CellUsed, CellAllowed, CellSelf, CellViolation, CellForbidden}, matrix.Cells[3])
+
+	var buf bytes.Buffer
+	require.NoError(t, matrix.WriteCSV(&buf))
+	assert.Equal(t, `importer,pkg/errors,domain/entity,domain/valueobject,app/usecase,infra/db,infra/cache
+pkg/errors,self,forbidden,forbidden,forbidden,forbidden,forbidden
+domain/entity,used,self,violation,forbidden,forbidden,forbidden
+domain/valueobject,used,allowed,self,forbidden,violation,forbidden
+app/usecase,allowed,used,allowed,self,violation,forbidden
+infra/db,allowed,used,allowed,allowed,self,forbidden
+infra/cache,allowed,allowed,allowed,allowed,allowed,self
+`, buf.String())
+}
+
+func TestNewMatrix_RulesOnly(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: "Application layer", Order: 2, Packages: []Package{{Path: "app/service"}, {Path: "app/usecase", Level: 1}, {Path: "app/query"}}},
+			{Name: "Domain layer", Order: 1, Packages: []Package{{Path: "domain"}}},
+		},
+	}
+
+	matrix := NewMatrix(config, nil)
+
+	assert.Equal(t, []LayerPath{"domain", "app/service", "app/query", "app/usecase"}, matrix.Packages)
+
+	var buf bytes.Buffer
+	require.NoError(t, matrix.WriteMarkdown(&buf))
+	assert.Equal(t, `| importer \ imported | domain | app/service | app/query | app/usecase |
+|---|:-:|:-:|:-:|:-:|
+| domain | - |  |  |  |
+| app/service | ✓ | - |  |  |
+| app/query | ✓ | ✓ | - |  |
+| app/usecase | ✓ | ✓ | ✓ | - |
+
+✓ allowed, ● imported, ✗ imported although forbidden, empty: forbidden
+`, buf.String())
+}
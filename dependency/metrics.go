@@ -0,0 +1,205 @@
+package dependency
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+)
+
+// Metrics are the coupling metrics of the listed packages and their layers,
+// after Robert C. Martin's package design principles
+type Metrics struct {
+	Packages []PackageMetrics `json:"packages"`
+	Layers   []LayerMetrics   `json:"layers"`
+}
+
+// Coupling holds the metrics shared by packages and layers
+type Coupling struct {
+	Afferent     int      `json:"afferent"`     // Ca: packages outside that import it
+	Efferent     int      `json:"efferent"`     // Ce: packages outside that it imports
+	Instability  *float64 `json:"instability"`  // Ce / (Ca + Ce), nil without coupling
+	Abstractness float64  `json:"abstractness"` // Exported interface types / exported types
+	Distance     *float64 `json:"distance"`     // |A + I - 1|, distance from the main sequence, nil with I
+}
+
+// PackageMetrics are the metrics of a listed package. Warnings tell how
+// its metrics contradict its position in DEPENDENCY.md.
+type PackageMetrics struct {
+	Path  LayerPath `json:"path"`
+	Layer LayerName `json:"layer"` // Empty for shared packages
+	Coupling
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// LayerMetrics are the metrics of a layer, counting only packages outside it
+type LayerMetrics struct {
+	Name  LayerName `json:"name"` // Empty for the shared packages
+	Order int       `json:"order"`
+	Coupling
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// newCoupling computes the metrics from the coupling and type counts
+func newCoupling(afferent, efferent, types, interfaces int) Coupling {
+	c := Coupling{Afferent: afferent, Efferent: efferent}
+	if types > 0 {
+		c.Abstractness = float64(interfaces) / float64(types)
+	}
+	if afferent+efferent > 0 {
+		instability := float64(efferent) / float64(afferent+efferent)
+		distance := math.Abs(c.Abstractness + instability - 1)
+		c.Instability, c.Distance = &instability, &distance
+	}
+	return c
+}
+
+// ComputeMetrics computes the metrics of every listed package and layer from
+// the import graph. A package is flagged when it imports a less stable
+// package, and a layer when it is less stable than a layer below it, since
+// upper layers should be the ones that are hard to change. Packages and
+// layers without coupling have no instability and are never compared.
+func ComputeMetrics(config *DependencyConfig, graph *ImportGraph) *Metrics {
+	metrics := &Metrics{}
+
+	type group struct {
+		name     LayerName
+		order    int
+		packages []Package
+	}
+	var groups []group
+	if len(config.Shared) > 0 {
+		groups = append(groups, group{packages: config.Shared})
+	}
+	for _, layer := range config.Layers {
+		groups = append(groups, group{name: layer.Name, order: layer.Order, packages: layer.Packages})
+	}
+
+	instability := make(map[LayerPath]*float64)
+	for _, g := range groups {
+		for _, pkg := range g.packages {
+			c := newCoupling(len(graph.ImportedBy(pkg.Path)), len(graph.Imports[pkg.Path]), graph.Types[pkg.Path], graph.Interfaces[pkg.Path])
+			instability[pkg.Path] = c.Instability
+			metrics.Packages = append(metrics.Packages, PackageMetrics{Path: pkg.Path, Layer: g.name, Coupling: c})
+		}
+	}
+
+	for i := range metrics.Packages {
+		pkg := &metrics.Packages[i]
+		for _, imported := range graph.Imports[pkg.Path] {
+			if lessStable(instability[imported], pkg.Instability) {
+				pkg.Warnings = append(pkg.Warnings, fmt.Sprintf("imports less stable %s (I=%.2f > %.2f)", imported, *instability[imported], *pkg.Instability))
+			}
+		}
+	}
+
+	for _, g := range groups {
+		inside := make(map[LayerPath]bool, len(g.packages))
+		for _, pkg := range g.packages {
+			inside[pkg.Path] = true
+		}
+		importers := make(map[LayerPath]bool)
+		imported := make(map[LayerPath]bool)
+		var types, interfaces int
+		for _, pkg := range g.packages {
+			for _, importer := range graph.ImportedBy(pkg.Path) {
+				if !inside[importer] {
+					importers[importer] = true
+				}
+			}
+			for _, path := range graph.Imports[pkg.Path] {
+				if !inside[path] {
+					imported[path] = true
+				}
+			}
+			types += graph.Types[pkg.Path]
+			interfaces += graph.Interfaces[pkg.Path]
+		}
+		metrics.Layers = append(metrics.Layers, LayerMetrics{
+			Name:     g.name,
+			Order:    g.order,
+			Coupling: newCoupling(len(importers), len(imported), types, interfaces),
+		})
+	}
+
+	// The shared packages sort first, above every layer
+	sort.SliceStable(metrics.Layers, func(i, j int) bool {
+		return metrics.Layers[i].Order < metrics.Layers[j].Order
+	})
+	for i := range metrics.Layers {
+		layer := &metrics.Layers[i]
+		for _, lower := range metrics.Layers[i+1:] {
+			if lower.Order > layer.Order && lessStable(layer.Instability, lower.Instability) {
+				layer.Warnings = append(layer.Warnings, fmt.Sprintf("less stable than lower layer %s (I=%.2f > %.2f)", lower.Name, *layer.Instability, *lower.Instability))
+			}
+		}
+	}
+
+	return metrics
+}
+
+// lessStable reports whether instability a is above b, when both are defined
+func lessStable(a, b *float64) bool {
+	return a != nil && b != nil && *a > *b
+}
+
+// Warnings returns the warnings of every package and layer, prefixed with its name
+func (m *Metrics) Warnings() []string {
+	var warnings []string
+	for _, layer := range m.Layers {
+		for _, warning := range layer.Warnings {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", layerLabel(layer.Name), warning))
+		}
+	}
+	for _, pkg := range m.Packages {
+		for _, warning := range pkg.Warnings {
+			warnings = append(warnings, fmt.Sprintf("%s: %s", pkg.Path, warning))
+		}
+	}
+	return warnings
+}
+
+// Write prints the metrics as tables for people, followed by the warnings
+func (m *Metrics) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LAYER\tCa\tCe\tI\tA\tD")
+	for _, layer := range m.Layers {
+		fmt.Fprintf(tw, "%s\t%s\n", layerLabel(layer.Name), layer.Coupling)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "PACKAGE\tCa\tCe\tI\tA\tD")
+	for _, pkg := range m.Packages {
+		fmt.Fprintf(tw, "%s\t%s\n", pkg.Path, pkg.Coupling)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, warning := range m.Warnings() {
+		if _, err := fmt.Fprintf(w, "warning: %s\n", warning); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c Coupling) String() string {
+	return fmt.Sprintf("%d\t%d\t%s\t%.2f\t%s", c.Afferent, c.Efferent, formatMetric(c.Instability), c.Abstractness, formatMetric(c.Distance))
+}
+
+// formatMetric prints a metric with two decimals, or "-" when it is undefined
+func formatMetric(value *float64) string {
+	if value == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", *value)
+}
+
+// layerLabel names a layer, or the shared packages when name is empty
+func layerLabel(name LayerName) string {
+	if name == "" {
+		return "Shared"
+	}
+	return string(name)
+}
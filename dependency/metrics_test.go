@@ -0,0 +1,180 @@
+package dependency
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// metricsProject writes a module whose domain layer depends on the infra layer
+func metricsProject(t *testing.T) (string, *DependencyConfig) {
+	t.Helper()
+	tmpDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), "module example.com/app\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(tmpDir, "pkg/errors/errors.go"), "package errors\n\ntype Error struct{}\n")
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity/user.go"), `package entity
+
+import (
+	_ "example.com/app/domain/valueobject"
+	_ "example.com/app/pkg/errors"
+)
+
+type User struct{}
+
+type Repository interface{ Find() User }
+
+type finder interface{}
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "domain/valueobject/email.go"), `package valueobject
+
+import (
+	_ "example.com/app/infra/db"
+	_ "example.com/app/pkg/errors"
+	_ "fmt"
+)
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "app/usecase/usecase.go"), `package usecase
+
+import (
+	_ "example.com/app/domain/entity"
+	_ "example.com/app/infra/db"
+)
+
+type Service struct{}
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "app/usecase/usecase_test.go"), `package usecase_test
+
+import _ "example.com/app/app/usecase"
+import _ "example.com/app/pkg/errors"
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "infra/db/db.go"), `package db
+
+import _ "example.com/app/domain/entity"
+
+type DB struct{}
+`)
+
+	config := &DependencyConfig{
+		Shared: []Package{{Path: "pkg/errors"}},
+		Layers: []Layer{
+			{Name: "Domain layer", Order: 1, Packages: []Package{{Path: "domain/entity"}, {Path: "domain/valueobject"}}},
+			{Name: "Application layer", Order: 2, Packages: []Package{{Path: "app/usecase"}}},
+			{Name: "Infra layer", Order: 3, Packages: []Package{{Path: "infra/db"}, {Path: "infra/cache"}}},
+		},
+	}
+	return tmpDir, config
+}
+
+func TestAnalyzer_BuildImportGraph(t *testing.T) {
+	tmpDir, config := metricsProject(t)
+
+	graph, err := NewAnalyzer().BuildImportGraph(context.Background(), tmpDir, config)
+	require.NoError(t, err)
+
+	assert.Equal(t, []LayerPath{"domain/valueobject", "pkg/errors"}, graph.Imports["domain/entity"])
+	assert.Equal(t, []LayerPath{"domain/entity", "infra/db"}, graph.Imports["app/usecase"])
+	assert.Equal(t, []LayerPath{"app/usecase", "infra/db"}, graph.ImportedBy("domain/entity"))
+	assert.True(t, graph.Imported("infra/db", "domain/entity"))
+	assert.False(t, graph.Imported("domain/entity", "infra/db"))
+	assert.Equal(t, 2, graph.Types["domain/entity"])
+	assert.Equal(t, 1, graph.Interfaces["domain/entity"])
+	assert.Equal(t, 1, graph.Types["app/usecase"])
+}
+
+func TestComputeMetrics(t *testing.T) {
+	tmpDir, config := metricsProject(t)
+	graph, err := NewAnalyzer().BuildImportGraph(context.Background(), tmpDir, config)
+	require.NoError(t, err)
+
+	metrics := ComputeMetrics(config, graph)
+
+	assert.Equal(t, PackageMetrics{
+		Path:     "domain/entity",
+		Layer:    "Domain layer",
+		Coupling: Coupling{Afferent: 2, Efferent: 2, Instability: metric(0.5), Abstractness: 0.5, Distance: metric(0)},
+		Warnings: []string{"imports less stable domain/valueobject (I=0.67 > 0.50)"},
+	}, metrics.Packages[1])
+
+	var buf bytes.Buffer
+	require.NoError(t, metrics.Write(&buf))
+	assert.Equal(t, `LAYER              Ca  Ce  I     A     D
+Shared             2   0   0.00  0.00  1.00
+Domain layer       2   2   0.50  0.50  0.00
+Application layer  0   2   1.00  0.00  0.00
+Infra layer        2   1   0.33  0.00  0.67
+
+PACKAGE             Ca  Ce  I     A     D
+pkg/errors          2   0   0.00  0.00  1.00
+domain/entity       2   2   0.50  0.50  0.00
+domain/valueobject  1   2   0.67  0.00  0.33
+app/usecase         0   2   1.00  0.00  0.00
+infra/db            2   1   0.33  0.00  0.67
+infra/cache         0   0   -     0.00  -
+warning: Domain layer: less stable than lower layer Infra layer (I=0.50 > 0.33)
+warning: Application layer: less stable than lower layer Infra layer (I=1.00 > 0.33)
+warning: domain/entity: imports less stable domain/valueobject (I=0.67 > 0.50)
+warning: infra/db: imports less stable domain/entity (I=0.50 > 0.33)
+`, buf.String())
+}
+
+func TestComputeMetrics_Uncoupled(t *testing.T) {
+	// Adapters has no coupling, and the layers are not listed by order
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: "Infra", Order: 3, Packages: []Package{{Path: "infra/db"}, {Path: "infra/cache"}}},
+			{Name: "Domain", Order: 1, Packages: []Package{{Path: "domain/entity"}}},
+			{Name: "Adapters", Order: 2, Packages: []Package{{Path: "adapters/http"}}},
+		},
+	}
+	graph := &ImportGraph{
+		Imports: map[LayerPath][]LayerPath{
+			"domain/entity": {"infra/db"},
+			"infra/db":      {"domain/entity"},
+			"infra/cache":   {"domain/entity"},
+		},
+	}
+
+	metrics := ComputeMetrics(config, graph)
+
+	var names []LayerName
+	for _, layer := range metrics.Layers {
+		names = append(names, layer.Name)
+	}
+	assert.Equal(t, []LayerName{"Domain", "Adapters", "Infra"}, names)
+	assert.Nil(t, metrics.Layers[1].Instability)
+	assert.Nil(t, metrics.Layers[1].Distance)
+	assert.Equal(t, []string{
+		"domain/entity: imports less stable infra/db (I=0.50 > 0.33)",
+	}, metrics.Warnings())
+}
+
+func metric(value float64) *float64 {
+	return &value
+}
+
+func TestAnalyzer_BuildImportGraph_SkipsGeneratedFiles(t *testing.T) {
+	tmpDir, config := metricsProject(t)
+	before, err := NewAnalyzer().BuildImportGraph(context.Background(), tmpDir, config)
+	require.NoError(t, err)
+	violations, err := NewAnalyzer().AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+
+	generator := NewGenerator()
+	generator.FileName = "deps.gen.go"
+	generator.GenerateTestFiles = true
+	_, err = generator.Run(context.Background(), tmpDir, config)
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(tmpDir, "app/usecase/deps.gen.go"))
+
+	after, err := NewAnalyzer().BuildImportGraph(context.Background(), tmpDir, config)
+	require.NoError(t, err)
+	assert.Equal(t, before.Imports, after.Imports)
+	afterViolations, err := NewAnalyzer().AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+	assert.Equal(t, violations, afterViolations)
+}
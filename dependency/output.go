@@ -0,0 +1,444 @@
+package dependency
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing/fstest"
+	"time"
+)
+
+// Output receives the files the generator writes. Names are slash-separated
+// and relative to the base directory.
+type Output interface {
+	MkdirAll(dir string) error
+	WriteFile(name string, data []byte) error
+}
+
+// ExistingOutput is an Output that already holds files. The generator reads
+// it to skip unchanged files and removes stale generated files from it.
+type ExistingOutput interface {
+	Output
+	FS() fs.FS
+	Remove(name string) error
+}
+
+// OSOutput writes files under Dir on the real file system
+type OSOutput struct {
+	Dir string
+}
+
+func NewOSOutput(dir string) *OSOutput {
+	return &OSOutput{Dir: dir}
+}
+
+func (o *OSOutput) MkdirAll(dir string) error {
+	target, err := o.resolve(dir)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(target, 0755)
+}
+
+// WriteFile writes to a temporary file in the same directory and renames it
+// into place, so that the file is never left half-written
+func (o *OSOutput) WriteFile(name string, data []byte) error {
+	target, err := o.resolve(name)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(target, data, 0644)
+}
+
+// ReplaceFile replaces the content of an existing file like
+// OSOutput.WriteFile does. A symlink is written through to its target, and
+// the file keeps its mode.
+func ReplaceFile(path string, data []byte) error {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(target, data, info.Mode().Perm())
+}
+
+// writeFileAtomic writes data to a temporary file next to target and
+// renames it over target
+func writeFileAtomic(target string, data []byte, mode fs.FileMode) error {
+	temp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Chmod(mode); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), target)
+}
+
+func (o *OSOutput) FS() fs.FS {
+	return os.DirFS(o.Dir)
+}
+
+func (o *OSOutput) Remove(name string) error {
+	target, err := o.resolve(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(target)
+}
+
+// resolve returns the path of name under Dir. It fails when name is not
+// local, or when a symlink on the way resolves outside Dir.
+func (o *OSOutput) resolve(name string) (string, error) {
+	local := filepath.FromSlash(name)
+	if filepath.IsAbs(local) || strings.HasPrefix(name, "/") {
+		return "", AbsolutePathError{Path: name}
+	}
+	if !filepath.IsLocal(local) {
+		return "", PathTraversalError{Path: name}
+	}
+	target := filepath.Join(o.Dir, local)
+
+	root, err := realPath(o.Dir)
+	if err != nil {
+		return "", err
+	}
+
+	// Resolve the longest part of the path that already exists
+	existing := target
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		existing = parent
+	}
+
+	resolved, err := realPath(existing)
+	if err != nil {
+		return "", err
+	}
+
+	relative, err := filepath.Rel(root, resolved)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", PathEscapeError{Path: target, Resolved: resolved}
+	}
+
+	return target, nil
+}
+
+// realPath returns the absolute path of p with every symlink resolved
+func realPath(p string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(resolved)
+}
+
+// MemoryOutput keeps written files in memory, keyed by name.
+// It is meant for tests and dry runs.
+type MemoryOutput map[string][]byte
+
+func NewMemoryOutput() MemoryOutput {
+	return MemoryOutput{}
+}
+
+func (m MemoryOutput) MkdirAll(dir string) error {
+	return nil
+}
+
+func (m MemoryOutput) WriteFile(name string, data []byte) error {
+	m[name] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m MemoryOutput) FS() fs.FS {
+	fsys := fstest.MapFS{}
+	for name, data := range m {
+		fsys[name] = &fstest.MapFile{Data: data, Mode: 0644}
+	}
+	return fsys
+}
+
+func (m MemoryOutput) Remove(name string) error {
+	if _, ok := m[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m, name)
+	return nil
+}
+
+// Names returns the sorted names of the written files
+func (m MemoryOutput) Names() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// archiveModTime is used for every archive entry so that archives are reproducible
+var archiveModTime = time.Unix(0, 0).UTC()
+
+// archiveDirs returns the directory entries for dir and its parents
+// that have not been written yet, outermost first
+func archiveDirs(dir string, written map[string]bool) []string {
+	var dirs []string
+	for dir = path.Clean(dir); dir != "." && dir != "/" && !written[dir]; dir = path.Dir(dir) {
+		written[dir] = true
+		dirs = append([]string{dir}, dirs...)
+	}
+	return dirs
+}
+
+// TarOutput writes files into a tar archive. Close must be called to
+// finish the archive; it does not close the underlying writer.
+type TarOutput struct {
+	writer *tar.Writer
+	dirs   map[string]bool
+}
+
+func NewTarOutput(w io.Writer) *TarOutput {
+	return &TarOutput{writer: tar.NewWriter(w), dirs: map[string]bool{}}
+}
+
+func (o *TarOutput) MkdirAll(dir string) error {
+	for _, d := range archiveDirs(dir, o.dirs) {
+		err := o.writer.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     d + "/",
+			Mode:     0755,
+			ModTime:  archiveModTime,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *TarOutput) WriteFile(name string, data []byte) error {
+	err := o.writer.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  archiveModTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = o.writer.Write(data)
+	return err
+}
+
+func (o *TarOutput) Close() error {
+	return o.writer.Close()
+}
+
+// ZipOutput writes files into a zip archive. Close must be called to
+// finish the archive; it does not close the underlying writer.
+type ZipOutput struct {
+	writer *zip.Writer
+	dirs   map[string]bool
+}
+
+func NewZipOutput(w io.Writer) *ZipOutput {
+	return &ZipOutput{writer: zip.NewWriter(w), dirs: map[string]bool{}}
+}
+
+func (o *ZipOutput) MkdirAll(dir string) error {
+	for _, d := range archiveDirs(dir, o.dirs) {
+		header := &zip.FileHeader{Name: d + "/", Modified: archiveModTime}
+		header.SetMode(fs.ModeDir | 0755)
+		if _, err := o.writer.CreateHeader(header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *ZipOutput) WriteFile(name string, data []byte) error {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: archiveModTime}
+	header.SetMode(0644)
+	w, err := o.writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (o *ZipOutput) Close() error {
+	return o.writer.Close()
+}
+
+// FileDiff is a written or removed file whose content differs from the previous one
+type FileDiff struct {
+	Name string
+	// Old is nil when the file did not exist before
+	Old []byte
+	// New is nil when the file was removed
+	New []byte
+}
+
+// Unified returns the change as a unified diff
+func (d FileDiff) Unified() string {
+	return unifiedDiff(d.Name, d.Old, d.New)
+}
+
+// DiffOutput compares every written file with the one in Previous,
+// records the differences and then passes the write on to Next
+type DiffOutput struct {
+	Next     Output
+	Previous fs.FS
+
+	diffs []FileDiff
+}
+
+func NewDiffOutput(next Output, previous fs.FS) *DiffOutput {
+	return &DiffOutput{Next: next, Previous: previous}
+}
+
+func (o *DiffOutput) MkdirAll(dir string) error {
+	return o.Next.MkdirAll(dir)
+}
+
+func (o *DiffOutput) WriteFile(name string, data []byte) error {
+	old, err := fs.ReadFile(o.Previous, name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err != nil || string(old) != string(data) {
+		o.diffs = append(o.diffs, FileDiff{Name: name, Old: old, New: append([]byte(nil), data...)})
+	}
+	return o.Next.WriteFile(name, data)
+}
+
+// FS returns the previous files, so that the generator skips files that
+// would not change
+func (o *DiffOutput) FS() fs.FS {
+	return o.Previous
+}
+
+// Remove records the removal and passes it on to Next when Next holds files
+func (o *DiffOutput) Remove(name string) error {
+	old, err := fs.ReadFile(o.Previous, name)
+	if err != nil {
+		return err
+	}
+	o.diffs = append(o.diffs, FileDiff{Name: name, Old: old})
+
+	if next, ok := o.Next.(ExistingOutput); ok {
+		if err := next.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Diffs returns the recorded differences in write order
+func (o *DiffOutput) Diffs() []FileDiff {
+	return o.diffs
+}
+
+// Unified returns all recorded differences as one unified diff
+func (o *DiffOutput) Unified() string {
+	var b strings.Builder
+	for _, diff := range o.diffs {
+		b.WriteString(diff.Unified())
+	}
+	return b.String()
+}
+
+// unifiedDiff renders the whole file as a single hunk
+func unifiedDiff(name string, old, new []byte) string {
+	oldLines := splitLines(old)
+	newLines := splitLines(new)
+
+	var b strings.Builder
+	if old == nil {
+		b.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&b, "--- a/%s\n", name)
+	}
+	if new == nil {
+		b.WriteString("+++ /dev/null\n")
+	} else {
+		fmt.Fprintf(&b, "+++ b/%s\n", name)
+	}
+	fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(len(oldLines)), hunkRange(len(newLines)))
+
+	// Longest common subsequence of lines, filled from the end
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			b.WriteString(" " + oldLines[i] + "\n")
+			i++
+			j++
+		case j < len(newLines) && (i == len(oldLines) || lcs[i][j+1] >= lcs[i+1][j]):
+			b.WriteString("+" + newLines[j] + "\n")
+			j++
+		default:
+			b.WriteString("-" + oldLines[i] + "\n")
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+func splitLines(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func hunkRange(lines int) string {
+	if lines == 0 {
+		return "0,0"
+	}
+	return fmt.Sprintf("1,%d", lines)
+}
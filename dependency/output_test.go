@@ -0,0 +1,244 @@
+package dependency
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOSOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	output := NewOSOutput(tmpDir)
+
+	require.NoError(t, output.MkdirAll("domain/entity"))
+	require.NoError(t, output.WriteFile("domain/entity/dependency.gen.go", []byte("package entity\n")))
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "domain", "entity", "dependency.gen.go"))
+	require.NoError(t, err)
+	assert.Equal(t, "package entity\n", string(content))
+}
+
+func TestMemoryOutput(t *testing.T) {
+	output := NewMemoryOutput()
+
+	data := []byte("package entity\n")
+	require.NoError(t, output.MkdirAll("domain/entity"))
+	require.NoError(t, output.WriteFile("domain/entity/dependency.gen.go", data))
+	require.NoError(t, output.WriteFile("app/usecase/dependency.gen.go", []byte("package usecase\n")))
+
+	// The written data is copied
+	data[0] = 'X'
+
+	assert.Equal(t, []string{"app/usecase/dependency.gen.go", "domain/entity/dependency.gen.go"}, output.Names())
+	assert.Equal(t, "package entity\n", string(output["domain/entity/dependency.gen.go"]))
+}
+
+func TestTarOutput(t *testing.T) {
+	var buf bytes.Buffer
+	output := NewTarOutput(&buf)
+
+	require.NoError(t, output.MkdirAll("domain/entity"))
+	require.NoError(t, output.WriteFile("domain/entity/dependency.gen.go", []byte("package entity\n")))
+	require.NoError(t, output.MkdirAll("domain/service"))
+	require.NoError(t, output.WriteFile("domain/service/dependency.gen.go", []byte("package service\n")))
+	require.NoError(t, output.Close())
+
+	reader := tar.NewReader(&buf)
+	var names []string
+	files := map[string]string{}
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		if header.Typeflag == tar.TypeReg {
+			files[header.Name] = string(content)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"domain/",
+		"domain/entity/",
+		"domain/entity/dependency.gen.go",
+		"domain/service/",
+		"domain/service/dependency.gen.go",
+	}, names)
+	assert.Equal(t, "package service\n", files["domain/service/dependency.gen.go"])
+}
+
+func TestZipOutput(t *testing.T) {
+	var buf bytes.Buffer
+	output := NewZipOutput(&buf)
+
+	require.NoError(t, output.MkdirAll("domain/entity"))
+	require.NoError(t, output.WriteFile("domain/entity/dependency.gen.go", []byte("package entity\n")))
+	require.NoError(t, output.Close())
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{"domain/", "domain/entity/", "domain/entity/dependency.gen.go"}, names)
+
+	file, err := reader.Open("domain/entity/dependency.gen.go")
+	require.NoError(t, err)
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	require.NoError(t, err)
+	assert.Equal(t, "package entity\n", string(content))
+}
+
+func TestDiffOutput(t *testing.T) {
+	previous := fstest.MapFS{
+		"domain/entity/dependency.gen.go":  &fstest.MapFile{Data: []byte("package entity\n")},
+		"app/usecase/dependency.gen.go":    &fstest.MapFile{Data: []byte("package usecase\n\nimport (\n\t_ \"example.com/domain/entity\"\n)\n")},
+		"domain/service/dependency.gen.go": &fstest.MapFile{Data: []byte("package service\n")},
+	}
+
+	next := NewMemoryOutput()
+	output := NewDiffOutput(next, previous)
+
+	require.NoError(t, output.WriteFile("domain/entity/dependency.gen.go", []byte("package entity\n")))
+	require.NoError(t, output.WriteFile("app/usecase/dependency.gen.go", []byte("package usecase\n\nimport (\n\t_ \"example.com/domain/entity\"\n\t_ \"example.com/domain/service\"\n)\n")))
+	require.NoError(t, output.WriteFile("infra/db/dependency.gen.go", []byte("package db\n")))
+
+	// Every write reaches the next output
+	assert.Len(t, next, 3)
+
+	diffs := output.Diffs()
+	require.Len(t, diffs, 2)
+	assert.Equal(t, "app/usecase/dependency.gen.go", diffs[0].Name)
+	assert.Equal(t, "infra/db/dependency.gen.go", diffs[1].Name)
+	assert.Nil(t, diffs[1].Old)
+
+	expected := strings.Join([]string{
+		"--- a/app/usecase/dependency.gen.go",
+		"+++ b/app/usecase/dependency.gen.go",
+		"@@ -1,5 +1,6 @@",
+		" package usecase",
+		" ",
+		" import (",
+		" \t_ \"example.com/domain/entity\"",
+		"+\t_ \"example.com/domain/service\"",
+		" )",
+		"--- /dev/null",
+		"+++ b/infra/db/dependency.gen.go",
+		"@@ -0,0 +1,1 @@",
+		"+package db",
+		"",
+	}, "\n")
+	assert.Equal(t, expected, output.Unified())
+}
+
+func TestDiffOutput_Remove(t *testing.T) {
+	previous := fstest.MapFS{
+		"infra/old/dependency.gen.go": &fstest.MapFile{Data: []byte("package old\n")},
+	}
+
+	output := NewDiffOutput(NewMemoryOutput(), previous)
+	require.NoError(t, output.Remove("infra/old/dependency.gen.go"))
+
+	expected := strings.Join([]string{
+		"--- a/infra/old/dependency.gen.go",
+		"+++ /dev/null",
+		"@@ -1,1 +0,0 @@",
+		"-package old",
+		"",
+	}, "\n")
+	assert.Equal(t, expected, output.Unified())
+}
+
+func TestOSOutput_Confinement(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "domain"), 0755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "domain", "linked")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "target.go"), filepath.Join(root, "domain", "file.go")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "domain"), filepath.Join(root, "inside")))
+
+	output := NewOSOutput(root)
+
+	t.Run("absolute path", func(t *testing.T) {
+		err := output.WriteFile(filepath.ToSlash(filepath.Join(outside, "x.go")), []byte("package x\n"))
+		assert.ErrorAs(t, err, &AbsolutePathError{})
+	})
+
+	t.Run("parent directory", func(t *testing.T) {
+		err := output.WriteFile("../x.go", []byte("package x\n"))
+		assert.ErrorAs(t, err, &PathTraversalError{})
+		err = output.MkdirAll("domain/../../x")
+		assert.ErrorAs(t, err, &PathTraversalError{})
+	})
+
+	t.Run("symlinked directory", func(t *testing.T) {
+		err := output.MkdirAll("domain/linked/entity")
+		assert.ErrorAs(t, err, &PathEscapeError{})
+		err = output.WriteFile("domain/linked/dependency.gen.go", []byte("package linked\n"))
+		assert.ErrorAs(t, err, &PathEscapeError{})
+
+		entries, err := os.ReadDir(outside)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("symlinked file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(outside, "target.go"), []byte("package target\n"), 0644))
+		defer os.Remove(filepath.Join(outside, "target.go"))
+
+		err := output.WriteFile("domain/file.go", []byte("package domain\n"))
+		assert.ErrorAs(t, err, &PathEscapeError{})
+		err = output.Remove("domain/file.go")
+		assert.ErrorAs(t, err, &PathEscapeError{})
+
+		content, err := os.ReadFile(filepath.Join(outside, "target.go"))
+		require.NoError(t, err)
+		assert.Equal(t, "package target\n", string(content))
+	})
+
+	t.Run("symlink inside the root", func(t *testing.T) {
+		require.NoError(t, output.WriteFile("inside/dependency.gen.go", []byte("package domain\n")))
+		assert.FileExists(t, filepath.Join(root, "domain", "dependency.gen.go"))
+	})
+}
+
+func TestReplaceFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "docs/DEPENDENCY.md")
+	writeTestFile(t, target, "old\n")
+	require.NoError(t, os.Chmod(target, 0600))
+	link := filepath.Join(tmpDir, "DEPENDENCY.md")
+	require.NoError(t, os.Symlink("docs/DEPENDENCY.md", link))
+
+	require.NoError(t, ReplaceFile(link, []byte("new\n")))
+
+	// The symlink still points to the target, which keeps its mode
+	destination, err := os.Readlink(link)
+	require.NoError(t, err)
+	assert.Equal(t, "docs/DEPENDENCY.md", destination)
+	content, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "new\n", string(content))
+	info, err := os.Stat(target)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	assert.ErrorIs(t, ReplaceFile(filepath.Join(tmpDir, "missing.md"), nil), os.ErrNotExist)
+}
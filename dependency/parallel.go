@@ -0,0 +1,64 @@
+package dependency
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// workerCount returns the number of workers to use, GOMAXPROCS when unset
+func workerCount(workers int) int {
+	if workers < 1 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return workers
+}
+
+// forEach calls fn for 0 <= i < n on up to workers goroutines and returns
+// the errors in index order. Once ctx is done, or after the first error when
+// stopOnError is set, indices that have not started are skipped.
+func forEach(ctx context.Context, workers int, n int, stopOnError bool, fn func(i int) error) []error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indices := make(chan int)
+	go func() {
+		defer close(indices)
+		for i := 0; i < n; i++ {
+			select {
+			case indices <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workerCount(workers), n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(i); err != nil {
+					errs[i] = err
+					if stopOnError {
+						cancel()
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var result []error
+	for _, err := range errs {
+		if err != nil {
+			result = append(result, err)
+		}
+	}
+	return result
+}
@@ -0,0 +1,94 @@
+package dependency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEach(t *testing.T) {
+	var calls atomic.Int64
+	errs := forEach(context.Background(), 4, 100, false, func(i int) error {
+		calls.Add(1)
+		if i%10 == 3 {
+			return fmt.Errorf("failed %d", i)
+		}
+		return nil
+	})
+
+	assert.Equal(t, int64(100), calls.Load())
+	assert.Len(t, errs, 10)
+	for i, err := range errs {
+		assert.EqualError(t, err, fmt.Sprintf("failed %d", i*10+3))
+	}
+}
+
+func TestForEach_StopOnError(t *testing.T) {
+	var calls atomic.Int64
+	errs := forEach(context.Background(), 1, 100, true, func(i int) error {
+		calls.Add(1)
+		if i == 5 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	assert.Equal(t, []error{errors.New("failed")}, errs)
+	assert.Less(t, calls.Load(), int64(100))
+}
+
+func TestForEach_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls atomic.Int64
+	errs := forEach(ctx, 2, 100, false, func(i int) error {
+		if calls.Add(1) == 10 {
+			cancel()
+		}
+		return nil
+	})
+
+	assert.Empty(t, errs)
+	assert.Less(t, calls.Load(), int64(100))
+}
+
+func TestGenerator_Run_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	output := NewMemoryOutput()
+	generator := NewGenerator()
+	generator.Output = output
+	generator.ModuleName = ModuleName("example.com/monorepo")
+
+	_, err := generator.Run(ctx, ".", syntheticConfig(50, 3, 1))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, output)
+}
+
+func TestGenerator_Run_WorkersAreDeterministic(t *testing.T) {
+	config := syntheticConfig(500, 6, 2)
+
+	var results []*GenerateResult
+	var outputs []MemoryOutput
+	for _, workers := range []int{1, 8} {
+		output := NewMemoryOutput()
+		generator := NewGenerator()
+		generator.Output = output
+		generator.ModuleName = ModuleName("example.com/monorepo")
+		generator.GenerateTestFiles = true
+		generator.Workers = workers
+
+		result, err := generator.Run(context.Background(), ".", config)
+		assert.NoError(t, err)
+		results = append(results, result)
+		outputs = append(outputs, output)
+	}
+
+	assert.Equal(t, results[0], results[1])
+	assert.Equal(t, outputs[0], outputs[1])
+}
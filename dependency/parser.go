@@ -0,0 +1,767 @@
+package dependency
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	emptyLayerRegex = regexp.MustCompile(`^(\d+)\.\s*$`)
+	layerRegex      = regexp.MustCompile(`^(\d+)\.\s+(.+)$`)
+	styleRegex      = regexp.MustCompile(`^##\s+Style:\s*(.*)$`)
+	testsRegex      = regexp.MustCompile(`^##\s+Tests:\s*(.*)$`)
+)
+
+// section identifies the part of DEPENDENCY.md being parsed
+type section int
+
+const (
+	sectionNone section = iota
+	sectionLayers
+	sectionPackages
+	sectionShared
+	sectionExternalImports
+	sectionBannedSymbols
+	sectionTests
+	sectionSeverities
+)
+
+var sectionHeaders = []struct {
+	prefix  string
+	section section
+}{
+	{"## Layers", sectionLayers},
+	{"## Packages in layers", sectionPackages},
+	{"## Shared", sectionShared},
+	{"## External imports", sectionExternalImports},
+	{"## Banned symbols", sectionBannedSymbols},
+	{"## Severities", sectionSeverities},
+}
+
+func parseSectionHeader(line string) (section, bool) {
+	for _, header := range sectionHeaders {
+		if strings.HasPrefix(line, header.prefix) {
+			return header.section, true
+		}
+	}
+	return sectionNone, false
+}
+
+type Parser struct{}
+
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+func (p *Parser) ParseDependencyFile(filePath string) (*DependencyConfig, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return p.ParseDependencyContent(file)
+}
+
+// ParseDependencyFS reads DEPENDENCY.md from a file system such as
+// embed.FS, fstest.MapFS or a zip archive
+func (p *Parser) ParseDependencyFS(fsys fs.FS, name string) (*DependencyConfig, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return p.ParseDependencyContent(file)
+}
+
+func (p *Parser) ParseDependencyContent(reader io.Reader) (*DependencyConfig, error) {
+	var lines []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	config := &DependencyConfig{
+		Layers: make([]Layer, 0),
+	}
+
+	// The Severities section decides whether the problems of the lines
+	// above it stop parsing, so it is read first
+	if err := p.parseSeverities(lines, config); err != nil {
+		return nil, err
+	}
+
+	currentSection := sectionNone
+	var currentLayer *Layer
+	var currentScope *RuleScope
+
+	// A package scope of the Banned Symbols section without symbols below
+	// it is most likely a symbol written without indentation
+	var bannedScope *RuleScope
+	var bannedScopeLine, bannedScopeRules int
+	closeBannedScope := func() error {
+		if bannedScope != nil && len(config.BannedSymbols) == bannedScopeRules {
+			err := ParseError{Line: bannedScopeLine, Err: fmt.Errorf("banned symbol scope %q lists no symbols", bannedScope.Pattern)}
+			if err := config.tolerate(RuleParseError, err); err != nil {
+				return err
+			}
+		}
+		bannedScope = nil
+		return nil
+	}
+
+	diagramLine := 0 // Line of the open diagram marker, 0 outside the diagram
+	for i, rawLine := range lines {
+		lineNumber := i + 1
+		line := strings.TrimSpace(rawLine)
+
+		// Skip the generated diagram, which only repeats the rules
+		if diagramLine == 0 && strings.Contains(line, DiagramBegin) {
+			diagramLine = lineNumber
+		}
+		if diagramLine != 0 {
+			if strings.Contains(line, DiagramEnd) {
+				diagramLine = 0
+			}
+			continue
+		}
+
+		// Check for section headers
+		if next, ok := parseSectionHeader(line); ok {
+			if err := closeBannedScope(); err != nil {
+				return nil, err
+			}
+			currentSection = next
+			currentLayer = nil
+			currentScope = nil
+			continue
+		}
+		if styleRegex.MatchString(line) {
+			if err := closeBannedScope(); err != nil {
+				return nil, err
+			}
+			currentSection = sectionNone
+			if err := p.ParseStyleHeading(line, config); err != nil {
+				if err := config.tolerate(RuleParseError, ParseError{Line: lineNumber, Err: err}); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		if testsRegex.MatchString(line) {
+			if err := closeBannedScope(); err != nil {
+				return nil, err
+			}
+			currentSection = sectionTests
+			currentScope = nil
+			if err := p.ParseTestsHeading(line, config); err != nil {
+				if err := config.tolerate(RuleParseError, ParseError{Line: lineNumber, Err: err}); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var err error
+		switch currentSection {
+		case sectionLayers:
+			err = p.ParseLayersSection(rawLine, config)
+		case sectionPackages:
+			err = p.ParsePackagesSection(rawLine, config, &currentLayer)
+			if err == nil && currentLayer != nil {
+				config.recordLine(currentLayer.Packages, lineNumber)
+			}
+		case sectionShared:
+			err = p.ParseSharedSection(rawLine, config)
+			if err == nil {
+				config.recordLine(config.Shared, lineNumber)
+			}
+		case sectionExternalImports:
+			err = p.ParseExternalImportsSection(rawLine, config, &currentScope)
+		case sectionBannedSymbols:
+			previous := currentScope
+			err = p.ParseBannedSymbolsSection(rawLine, config, &currentScope)
+			if currentScope != previous {
+				if err := closeBannedScope(); err != nil {
+					return nil, err
+				}
+				if currentScope != nil && currentScope.Pattern != "" {
+					bannedScope, bannedScopeLine, bannedScopeRules = currentScope, lineNumber, len(config.BannedSymbols)
+				}
+			}
+		case sectionTests:
+			err = p.ParseTestsSection(rawLine, config, &currentScope)
+		}
+		if err != nil {
+			if err := config.tolerate(RuleParseError, ParseError{Line: lineNumber, Err: err}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := closeBannedScope(); err != nil {
+		return nil, err
+	}
+
+	if diagramLine != 0 {
+		err := ParseError{Line: diagramLine, Err: fmt.Errorf("%s without %s", DiagramBegin, DiagramEnd)}
+		if err := config.tolerate(RuleParseError, err); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, validate := range []func() error{config.ValidateShared, config.ValidateStyle, config.ValidateRules} {
+		if err := validate(); err != nil {
+			if err := config.tolerate(RuleInvalidConfig, ValidationError{Err: err}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// parseSeverities parses the lines of the Severities section, skipping
+// the diagram like ParseDependencyContent does
+func (p *Parser) parseSeverities(lines []string, config *DependencyConfig) error {
+	inSection := false
+	inDiagram := false
+	for i, rawLine := range lines {
+		line := strings.TrimSpace(rawLine)
+
+		if !inDiagram && strings.Contains(line, DiagramBegin) {
+			inDiagram = true
+		}
+		if inDiagram {
+			inDiagram = !strings.Contains(line, DiagramEnd)
+			continue
+		}
+
+		if next, ok := parseSectionHeader(line); ok {
+			inSection = next == sectionSeverities
+			continue
+		}
+		if styleRegex.MatchString(line) || testsRegex.MatchString(line) {
+			inSection = false
+			continue
+		}
+		if !inSection || line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if err := p.ParseSeveritiesSection(rawLine, config); err != nil {
+			return ParseError{Line: i + 1, Err: err}
+		}
+	}
+	return nil
+}
+
+func (p *Parser) ParseStyleHeading(line string, config *DependencyConfig) error {
+	matches := styleRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if len(matches) != 2 {
+		return nil
+	}
+
+	style := Style(strings.ToLower(strings.TrimSpace(matches[1])))
+	if style == "" {
+		return fmt.Errorf("invalid style: style name cannot be empty")
+	}
+	if config.Style != "" {
+		return fmt.Errorf("invalid style: style is already set to %s", config.Style)
+	}
+
+	return config.ApplyStyle(style)
+}
+
+func (p *Parser) ParseTestsHeading(line string, config *DependencyConfig) error {
+	matches := testsRegex.FindStringSubmatch(strings.TrimSpace(line))
+	if len(matches) != 2 {
+		return nil
+	}
+
+	mode := TestMode(strings.ToLower(strings.TrimSpace(matches[1])))
+	if err := mode.Validate(); err != nil {
+		return fmt.Errorf("invalid test mode: %v", err)
+	}
+	if config.TestMode != "" {
+		return fmt.Errorf("invalid test mode: test mode is already set to %s", config.TestMode)
+	}
+
+	config.TestMode = mode
+	return nil
+}
+
+func (p *Parser) ParseLayersSection(line string, config *DependencyConfig) error {
+	trimmed := strings.TrimSpace(line)
+
+	// Skip description lines and non-numbered lines
+	if strings.HasPrefix(trimmed, "-") ||
+		strings.Contains(trimmed, "cannot depend") ||
+		trimmed == "" {
+		return nil
+	}
+
+	// Check for numbered lines with empty names like "1. "
+	// Using package-level emptyLayerRegex constant
+	if emptyLayerRegex.MatchString(trimmed) {
+		return fmt.Errorf("invalid layer name: layer name cannot be empty")
+	}
+
+	// Match numbered layer lines like "1. Domain layer"
+	// Using package-level layerRegex constant
+	matches := layerRegex.FindStringSubmatch(trimmed)
+
+	if len(matches) == 3 {
+		orderStr := matches[1]
+		layerName, severity := cutSeverity(matches[2])
+
+		order, err := strconv.Atoi(orderStr)
+		if err != nil {
+			return fmt.Errorf("invalid layer order: %s", orderStr)
+		}
+
+		layer := Layer{
+			Name:     LayerName(layerName),
+			Order:    order,
+			Packages: make([]Package, 0),
+			Severity: severity,
+		}
+
+		if err := layer.Name.Validate(); err != nil {
+			return fmt.Errorf("invalid layer name: %v", err)
+		}
+
+		// Layers of a preset are already defined, only allow restating them
+		// and setting their severity
+		if config.Style != "" {
+			existing := findLayer(config.Layers, layer.Name, layer.Order)
+			if existing == nil {
+				return fmt.Errorf("layer %q is not defined by the %s style", layer.Name, config.Style)
+			}
+			if severity != "" {
+				existing.Severity = severity
+			}
+			return nil
+		}
+
+		config.Layers = append(config.Layers, layer)
+	}
+
+	return nil
+}
+
+func (p *Parser) ParsePackagesSection(line string, config *DependencyConfig, currentLayer **Layer) error {
+	trimmed := strings.TrimSpace(line)
+
+	// Skip description lines
+	if strings.Contains(trimmed, "cannot depend") || trimmed == "" {
+		return nil
+	}
+
+	// Check for numbered lines with empty names like "1. "
+	// Using package-level emptyLayerRegex constant
+	if emptyLayerRegex.MatchString(trimmed) {
+		// Packages below a skipped layer line belong to no layer
+		*currentLayer = nil
+		return fmt.Errorf("invalid layer name: layer name cannot be empty")
+	}
+
+	// Match numbered layer lines like "1. Domain layer"
+	// Using package-level layerRegex constant
+	matches := layerRegex.FindStringSubmatch(trimmed)
+
+	if len(matches) == 3 {
+		orderStr := matches[1]
+		layerName, _ := cutSeverity(matches[2])
+
+		order, err := strconv.Atoi(orderStr)
+		if err != nil {
+			return fmt.Errorf("invalid layer order: %s", orderStr)
+		}
+
+		// Find the corresponding layer in config
+		layer := findLayer(config.Layers, LayerName(layerName), order)
+		if layer != nil {
+			*currentLayer = layer
+		} else if config.Style != "" {
+			*currentLayer = nil
+			return fmt.Errorf("layer %q is not defined by the %s style", layerName, config.Style)
+		}
+
+		return nil
+	}
+
+	// Match package lines with indentation
+	if strings.HasPrefix(trimmed, "- ") && *currentLayer != nil {
+		packagePath := strings.TrimPrefix(trimmed, "- ")
+		packagePath = strings.TrimSpace(packagePath)
+
+		if packagePath == "" {
+			return nil
+		}
+
+		// Calculate indentation level
+		level := p.calculateIndentationLevel(line)
+
+		normalized, err := LayerPath(packagePath).Normalize()
+		if err != nil {
+			return fmt.Errorf("invalid package path: %w", err)
+		}
+
+		pkg := Package{
+			Path:  normalized,
+			Level: level,
+		}
+
+		(*currentLayer).Packages = append((*currentLayer).Packages, pkg)
+	}
+
+	return nil
+}
+
+func (p *Parser) ParseSharedSection(line string, config *DependencyConfig) error {
+	trimmed := strings.TrimSpace(line)
+
+	// Skip description lines and anything that is not a package line
+	if !strings.HasPrefix(trimmed, "- ") {
+		return nil
+	}
+
+	packagePath := strings.TrimSpace(strings.TrimPrefix(trimmed, "- "))
+	if packagePath == "" {
+		return nil
+	}
+
+	normalized, err := LayerPath(packagePath).Normalize()
+	if err != nil {
+		return fmt.Errorf("invalid shared package path: %w", err)
+	}
+
+	pkg := Package{
+		Path:  normalized,
+		Level: p.calculateIndentationLevel(line),
+	}
+
+	config.Shared = append(config.Shared, pkg)
+
+	return nil
+}
+
+// ParseSeveritiesSection parses lines like "- external-import: warning"
+// that change the severity of a rule's findings
+func (p *Parser) ParseSeveritiesSection(line string, config *DependencyConfig) error {
+	trimmed := strings.TrimSpace(line)
+
+	// Skip description lines and anything that is not a list item
+	if !strings.HasPrefix(trimmed, "- ") {
+		return nil
+	}
+
+	ruleID, value, ok := strings.Cut(strings.TrimPrefix(trimmed, "- "), ":")
+	if !ok {
+		return fmt.Errorf("invalid severity %q: want \"- rule-id: severity\"", trimmed)
+	}
+	ruleID = strings.TrimSpace(ruleID)
+	severity := Severity(strings.TrimSpace(value))
+
+	if err := validateSeverityRule(ruleID); err != nil {
+		return err
+	}
+	if err := severity.Validate(); err != nil {
+		return err
+	}
+	if _, ok := config.Severities[ruleID]; ok {
+		return fmt.Errorf("the severity of %s is set more than once", ruleID)
+	}
+
+	if config.Severities == nil {
+		config.Severities = map[string]Severity{}
+	}
+	config.Severities[ruleID] = severity
+
+	return nil
+}
+
+func (p *Parser) ParseExternalImportsSection(line string, config *DependencyConfig, currentScope **RuleScope) error {
+	trimmed := strings.TrimSpace(line)
+
+	// Match layer scopes like "1. Domain layer"
+	scope, err := p.parseLayerScope(trimmed)
+	if err != nil {
+		// Rules below a skipped scope line belong to no scope
+		*currentScope = nil
+		return err
+	}
+	if scope != nil {
+		*currentScope = scope
+		return nil
+	}
+
+	// Skip description lines
+	item, ok := strings.CutPrefix(trimmed, "- ")
+	if !ok {
+		return nil
+	}
+	item, severity := cutSeverity(item)
+
+	// Match rule lines like "- forbid net/http"
+	action, pattern, _ := strings.Cut(item, " ")
+	if action == "allow" || action == "forbid" {
+		if *currentScope == nil {
+			return fmt.Errorf("external import rule %q must follow a layer or package pattern", item)
+		}
+
+		rule := ExternalImportRule{
+			Scope:    **currentScope,
+			Allow:    action == "allow",
+			Pattern:  ImportPattern(strings.TrimSpace(pattern)),
+			Severity: cmp.Or(severity, (*currentScope).Severity),
+		}
+		if err := rule.Pattern.Validate(); err != nil {
+			return fmt.Errorf("invalid external import rule: %v", err)
+		}
+
+		config.ExternalImports = append(config.ExternalImports, rule)
+		return nil
+	}
+
+	// Match package scopes like "- domain/*"
+	scope = &RuleScope{Pattern: PackagePattern(item), Severity: severity}
+	if err := scope.Pattern.Validate(); err != nil {
+		*currentScope = nil
+		return fmt.Errorf("invalid external import scope: %v", err)
+	}
+	*currentScope = scope
+
+	return nil
+}
+
+func (p *Parser) ParseBannedSymbolsSection(line string, config *DependencyConfig, currentScope **RuleScope) error {
+	trimmed := strings.TrimSpace(line)
+
+	// Match layer scopes like "1. Domain layer"
+	scope, err := p.parseLayerScope(trimmed)
+	if err != nil {
+		// Rules below a skipped scope line belong to no scope
+		*currentScope = nil
+		return err
+	}
+	if scope != nil {
+		*currentScope = scope
+		return nil
+	}
+
+	// Skip description lines
+	item, ok := strings.CutPrefix(trimmed, "- ")
+	if !ok {
+		return nil
+	}
+	item, severity := cutSeverity(item)
+
+	// Match package scopes like "- domain/*", written without indentation
+	if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+		if symbol, err := ParseBannedSymbol(item); err == nil && token.IsExported(symbol.Name) {
+			*currentScope = nil
+			return fmt.Errorf("banned symbol %q must be indented below a layer or package pattern", item)
+		}
+		scope := &RuleScope{Pattern: PackagePattern(item), Severity: severity}
+		if err := scope.Pattern.Validate(); err != nil {
+			*currentScope = nil
+			return fmt.Errorf("invalid banned symbol scope: %v", err)
+		}
+		*currentScope = scope
+		return nil
+	}
+
+	// Match indented symbol lines like "  - time.Now"
+	if *currentScope == nil {
+		return fmt.Errorf("banned symbol %q must follow a layer or package pattern", item)
+	}
+
+	symbol, err := ParseBannedSymbol(item)
+	if err != nil {
+		return fmt.Errorf("invalid banned symbol: %v", err)
+	}
+
+	config.BannedSymbols = append(config.BannedSymbols, BannedSymbolRule{
+		Scope:    **currentScope,
+		Symbol:   symbol,
+		Severity: cmp.Or(severity, (*currentScope).Severity),
+	})
+
+	return nil
+}
+
+func (p *Parser) ParseTestsSection(line string, config *DependencyConfig, currentScope **RuleScope) error {
+	trimmed := strings.TrimSpace(line)
+
+	// Match layer scopes like "1. Domain layer"
+	scope, err := p.parseLayerScope(trimmed)
+	if err != nil {
+		// Rules below a skipped scope line belong to no scope
+		*currentScope = nil
+		return err
+	}
+	if scope != nil {
+		*currentScope = scope
+		return nil
+	}
+
+	// Skip description lines
+	item, ok := strings.CutPrefix(trimmed, "- ")
+	if !ok {
+		return nil
+	}
+	item = strings.TrimSpace(item)
+
+	// Match rule lines like "- allow infra/fake"
+	if pattern, ok := strings.CutPrefix(item, "allow "); ok {
+		if config.TestMode != TestModeCustom {
+			return fmt.Errorf("test import rule %q requires the %s test mode", item, TestModeCustom)
+		}
+		if *currentScope == nil {
+			return fmt.Errorf("test import rule %q must follow a layer or package pattern", item)
+		}
+
+		rule := TestImportRule{
+			Scope:   **currentScope,
+			Pattern: PackagePattern(strings.TrimSpace(pattern)),
+		}
+		if err := rule.Pattern.Validate(); err != nil {
+			return fmt.Errorf("invalid test import rule: %v", err)
+		}
+
+		config.TestImports = append(config.TestImports, rule)
+		return nil
+	}
+
+	// Match package scopes like "- domain/*"
+	scope = &RuleScope{Pattern: PackagePattern(item)}
+	if err := scope.Pattern.Validate(); err != nil {
+		*currentScope = nil
+		return fmt.Errorf("invalid test import scope: %v", err)
+	}
+	*currentScope = scope
+
+	return nil
+}
+
+// parseLayerScope returns the scope of a numbered layer line, or nil for other lines
+func (p *Parser) parseLayerScope(trimmed string) (*RuleScope, error) {
+	if emptyLayerRegex.MatchString(trimmed) {
+		return nil, fmt.Errorf("invalid layer name: layer name cannot be empty")
+	}
+
+	matches := layerRegex.FindStringSubmatch(trimmed)
+	if len(matches) != 3 {
+		return nil, nil
+	}
+
+	order, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid layer order: %s", matches[1])
+	}
+
+	name, severity := cutSeverity(matches[2])
+	return &RuleScope{
+		Layer:    LayerName(name),
+		Order:    order,
+		Severity: severity,
+	}, nil
+}
+
+// cutSeverity removes a trailing severity like "(warning)", which a layer,
+// scope or rule line sets for the findings of its rules
+func cutSeverity(text string) (string, Severity) {
+	text = strings.TrimSpace(text)
+	rest, ok := strings.CutSuffix(text, ")")
+	if !ok {
+		return text, ""
+	}
+	index := strings.LastIndex(rest, "(")
+	if index < 0 {
+		return text, ""
+	}
+	severity := Severity(strings.TrimSpace(rest[index+1:]))
+	if !severity.IsValid() {
+		return text, ""
+	}
+	return strings.TrimSpace(rest[:index]), severity
+}
+
+func (p *Parser) calculateIndentationLevel(line string) int {
+	// Count leading spaces before the "- " marker
+	spacesBeforeDash := 0
+	for _, char := range line {
+		if char == ' ' {
+			spacesBeforeDash++
+		} else if char == '-' {
+			break
+		} else {
+			// Non-space, non-dash character before dash
+			break
+		}
+	}
+
+	// Convert spaces to indentation level using 4-space standard
+	// This supports arbitrary nesting depth:
+	// Level 0: 0-3 spaces
+	// Level 1: 4-7 spaces ("    - ", "      - ")
+	// Level 2: 8-11 spaces ("        - ")
+	// Level n: 4*n to 4*n+3 spaces (supports unlimited depth)
+	return spacesBeforeDash / 4
+}
+
+func (p *Parser) GetModuleName(goModPath string) (ModuleName, error) {
+	file, err := os.Open(goModPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return p.GetModuleNameFromContent(file, goModPath)
+}
+
+// GetModuleNameFS reads the module name from a go.mod in a file system
+func (p *Parser) GetModuleNameFS(fsys fs.FS, name string) (ModuleName, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return p.GetModuleNameFromContent(file, name)
+}
+
+func (p *Parser) GetModuleNameFromContent(reader io.Reader, sourceName string) (ModuleName, error) {
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			moduleName := ModuleName(strings.TrimSpace(strings.TrimPrefix(line, "module ")))
+			if err := moduleName.Validate(); err != nil {
+				return "", fmt.Errorf("invalid module name: %v", err)
+			}
+			return moduleName, nil
+		}
+	}
+
+	return "", ModuleNotFoundError{Source: sourceName}
+}
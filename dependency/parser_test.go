@@ -1,8 +1,14 @@
-package main
+package dependency
 
 import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/fs"
 	"os"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -144,7 +150,7 @@ Upper packages cannot depend on lower packages.
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			parser := NewParser()
-			config, err := parser.ParseDependencyContent(tt.content)
+			config, err := parser.ParseDependencyContent(strings.NewReader(tt.content))
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -493,7 +499,7 @@ go 1.21
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			parser := NewParser()
-			result, err := parser.GetModuleNameFromContent(tt.content, "test.mod")
+			result, err := parser.GetModuleNameFromContent(strings.NewReader(tt.content), "test.mod")
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -607,3 +613,378 @@ require (
 	expected := ModuleName("github.com/test/project")
 	assert.Equal(t, expected, result)
 }
+
+func TestParseDependencyContent_Style(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectError bool
+	}{
+		{
+			name: "style without layers section",
+			content: `## Style: hexagonal
+
+## Packages in layers
+
+1. Domain
+  - domain
+2. Ports
+  - ports
+3. Adapters
+  - adapters/http
+  - adapters/postgres
+`,
+		},
+		{
+			name: "style restating its layers",
+			content: `## Style: Hexagonal
+
+## Layers
+
+1. Domain
+  - Core entities
+2. Ports
+3. Adapters
+
+## Packages in layers
+
+1. Domain
+  - domain
+3. Adapters
+  - adapters/http
+`,
+		},
+		{
+			name: "unknown style",
+			content: `## Style: onion
+`,
+			expectError: true,
+		},
+		{
+			name: "layer not defined by style",
+			content: `## Style: hexagonal
+
+## Packages in layers
+
+1. Domain
+  - domain
+2. Infra
+  - infra
+`,
+			expectError: true,
+		},
+		{
+			name: "nested adapters",
+			content: `## Style: hexagonal
+
+## Packages in layers
+
+3. Adapters
+  - adapters/http
+    - adapters/postgres
+`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser()
+			config, err := parser.ParseDependencyContent(strings.NewReader(tt.content))
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, StyleHexagonal, config.Style)
+			require.Len(t, config.Layers, 3)
+			assert.NotEmpty(t, config.Layers[0].Packages)
+			assert.NotEmpty(t, config.Layers[2].Packages)
+		})
+	}
+}
+
+func TestParseSharedSection(t *testing.T) {
+	content := `## Shared
+
+Packages every layer may import.
+
+- pkg/errors
+- pkg/clock
+  - pkg/logging
+
+## Layers
+
+1. Domain layer
+
+## Packages in layers
+
+1. Domain layer
+  - domain/entity
+`
+
+	parser := NewParser()
+	config, err := parser.ParseDependencyContent(strings.NewReader(content))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Package{
+		{Path: LayerPath("pkg/errors"), Level: 0},
+		{Path: LayerPath("pkg/clock"), Level: 0},
+		{Path: LayerPath("pkg/logging"), Level: 0},
+	}, config.Shared)
+	require.Len(t, config.Layers, 1)
+	assert.Equal(t, []Package{{Path: LayerPath("domain/entity"), Level: 0}}, config.Layers[0].Packages)
+
+	_, err = parser.ParseDependencyContent(strings.NewReader("## Shared\n- ../pkg/errors\n"))
+	assert.Error(t, err)
+
+	_, err = parser.ParseDependencyContent(strings.NewReader(`## Shared
+- pkg/errors
+
+## Layers
+1. Domain layer
+
+## Packages in layers
+1. Domain layer
+  - pkg/errors
+`))
+	assert.Error(t, err)
+}
+
+func TestParseExternalImportsSection(t *testing.T) {
+	content := `## Layers
+
+1. Domain layer
+2. Infra layer
+
+## Packages in layers
+
+1. Domain layer
+  - domain/entity
+2. Infra layer
+  - infra/database
+
+## External imports
+
+Imports from outside the module.
+
+1. Domain layer
+  - forbid github.com/jackc/pgx/...
+  - forbid net/http
+- infra/*
+  - allow std
+  - allow github.com/jackc/pgx/...
+`
+
+	parser := NewParser()
+	config, err := parser.ParseDependencyContent(strings.NewReader(content))
+	require.NoError(t, err)
+
+	domain := RuleScope{Layer: LayerName("Domain layer"), Order: 1}
+	infra := RuleScope{Pattern: PackagePattern("infra/*")}
+	assert.Equal(t, []ExternalImportRule{
+		{Scope: domain, Allow: false, Pattern: ImportPattern("github.com/jackc/pgx/...")},
+		{Scope: domain, Allow: false, Pattern: ImportPattern("net/http")},
+		{Scope: infra, Allow: true, Pattern: ImportPattern("std")},
+		{Scope: infra, Allow: true, Pattern: ImportPattern("github.com/jackc/pgx/...")},
+	}, config.ExternalImports)
+
+	errorCases := map[string]string{
+		"rule without scope":  "## External imports\n- forbid net/http\n",
+		"empty pattern":       "## External imports\n- domain/*\n  - forbid \n",
+		"undefined layer":     "## External imports\n3. Missing layer\n  - forbid net/http\n",
+		"malformed scope":     "## External imports\n- domain/[\n",
+		"empty layer in rule": "## External imports\n1. \n",
+	}
+	for name, content := range errorCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parser.ParseDependencyContent(strings.NewReader(content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseBannedSymbolsSection(t *testing.T) {
+	content := `## Layers
+
+1. Domain layer
+
+## Packages in layers
+
+1. Domain layer
+  - domain/entity
+
+## Banned symbols
+
+Domain code must stay deterministic.
+
+1. Domain layer
+  - time.Now
+  - math/rand.Int
+- domain/*
+  - log.Printf
+`
+
+	parser := NewParser()
+	config, err := parser.ParseDependencyContent(strings.NewReader(content))
+	require.NoError(t, err)
+
+	domain := RuleScope{Layer: LayerName("Domain layer"), Order: 1}
+	assert.Equal(t, []BannedSymbolRule{
+		{Scope: domain, Symbol: BannedSymbol{ImportPath: "time", Name: "Now"}},
+		{Scope: domain, Symbol: BannedSymbol{ImportPath: "math/rand", Name: "Int"}},
+		{Scope: RuleScope{Pattern: PackagePattern("domain/*")}, Symbol: BannedSymbol{ImportPath: "log", Name: "Printf"}},
+	}, config.BannedSymbols)
+
+	errorCases := map[string]string{
+		"symbol without scope":  "## Banned symbols\n  - time.Now\n",
+		"malformed symbol":      "## Banned symbols\n- domain/*\n  - time\n",
+		"undefined layer":       "## Banned symbols\n2. Missing layer\n  - time.Now\n",
+		"scope without symbols": "## Banned symbols\n- domain/*\n- app/*\n  - time.Now\n",
+	}
+	for name, content := range errorCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parser.ParseDependencyContent(strings.NewReader(content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseBannedSymbolsSection_UnindentedSymbol(t *testing.T) {
+	content := `## Layers
+
+1. Domain
+
+## Banned symbols
+
+1. Domain
+- time.Now
+`
+
+	parser := NewParser()
+	_, err := parser.ParseDependencyContent(strings.NewReader(content))
+	assert.EqualError(t, err, `line 8: banned symbol "time.Now" must be indented below a layer or package pattern`)
+
+	content = strings.Replace(content, "## Banned symbols", "## Severities\n\n- parse-error: warning\n\n## Banned symbols", 1)
+	config, err := parser.ParseDependencyContent(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Empty(t, config.BannedSymbols)
+	require.Len(t, config.Warnings, 1)
+	assert.ErrorContains(t, config.Warnings[0], `banned symbol "time.Now" must be indented`)
+}
+
+func TestParseTestsSection(t *testing.T) {
+	content := `## Layers
+
+1. Domain layer
+2. Infra layer
+
+## Packages in layers
+
+1. Domain layer
+  - domain/entity
+2. Infra layer
+  - infra/fake
+
+## Tests: custom
+
+Tests may use fakes from the infra layer.
+
+1. Domain layer
+  - allow infra/fake
+- app/*
+  - allow infra/...
+`
+
+	parser := NewParser()
+	config, err := parser.ParseDependencyContent(strings.NewReader(content))
+	require.NoError(t, err)
+
+	assert.Equal(t, TestModeCustom, config.TestMode)
+	assert.Equal(t, []TestImportRule{
+		{Scope: RuleScope{Layer: LayerName("Domain layer"), Order: 1}, Pattern: PackagePattern("infra/fake")},
+		{Scope: RuleScope{Pattern: PackagePattern("app/*")}, Pattern: PackagePattern("infra/...")},
+	}, config.TestImports)
+
+	config, err = parser.ParseDependencyContent(strings.NewReader("## Tests: Relaxed\n"))
+	require.NoError(t, err)
+	assert.Equal(t, TestModeRelaxed, config.TestMode)
+
+	errorCases := map[string]string{
+		"unknown mode":          "## Tests: loose\n",
+		"mode set twice":        "## Tests: relaxed\n## Tests: custom\n",
+		"rule outside custom":   "## Tests: production\n- domain/*\n  - allow infra/fake\n",
+		"rule without scope":    "## Tests: custom\n- allow infra/fake\n",
+		"undefined layer scope": "## Tests: custom\n1. Missing layer\n  - allow infra/fake\n",
+	}
+	for name, content := range errorCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parser.ParseDependencyContent(strings.NewReader(content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseDependencyContent_Readers(t *testing.T) {
+	content := "## Layers\n1. Domain layer\n\n## Packages in layers\n1. Domain layer\n  - domain/entity\n"
+
+	readers := map[string]io.Reader{
+		"bytes.Reader":   bytes.NewReader([]byte(content)),
+		"bufio.Reader":   bufio.NewReader(strings.NewReader(content)),
+		"strings.Reader": strings.NewReader(content),
+		"io.MultiReader": io.MultiReader(strings.NewReader(content[:10]), strings.NewReader(content[10:])),
+	}
+
+	for name, reader := range readers {
+		t.Run(name, func(t *testing.T) {
+			parser := NewParser()
+			config, err := parser.ParseDependencyContent(reader)
+			require.NoError(t, err)
+			require.Len(t, config.Layers, 1)
+			assert.Equal(t, []Package{{Path: LayerPath("domain/entity"), Level: 0}}, config.Layers[0].Packages)
+		})
+	}
+}
+
+func TestParseDependencyFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"repo/DEPENDENCY.md": &fstest.MapFile{Data: []byte("## Layers\n1. Domain layer\n\n## Packages in layers\n1. Domain layer\n  - domain/entity\n")},
+		"repo/go.mod":        &fstest.MapFile{Data: []byte("module github.com/test/project\n\ngo 1.21\n")},
+	}
+
+	parser := NewParser()
+	config, err := parser.ParseDependencyFS(fsys, "repo/DEPENDENCY.md")
+	require.NoError(t, err)
+	require.Len(t, config.Layers, 1)
+	assert.Len(t, config.Layers[0].Packages, 1)
+
+	moduleName, err := parser.GetModuleNameFS(fsys, "repo/go.mod")
+	require.NoError(t, err)
+	assert.Equal(t, ModuleName("github.com/test/project"), moduleName)
+
+	_, err = parser.ParseDependencyFS(fsys, "missing/DEPENDENCY.md")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = parser.GetModuleNameFS(fsys, "missing/go.mod")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestParseDependencyContent_NormalizesPackagePaths(t *testing.T) {
+	content := "## Layers\n1. Domain layer\n\n## Shared\n- ./pkg/errors/\n\n## Packages in layers\n1. Domain layer\n  - ./domain/entity/\n  - domain//service\n"
+
+	config, err := NewParser().ParseDependencyContent(strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, []Package{{Path: LayerPath("pkg/errors")}}, config.Shared)
+	assert.Equal(t, []Package{
+		{Path: LayerPath("domain/entity"), Level: 0},
+		{Path: LayerPath("domain/service"), Level: 0},
+	}, config.Layers[0].Packages)
+
+	_, err = NewParser().ParseDependencyContent(strings.NewReader("## Layers\n1. Domain layer\n\n## Packages in layers\n1. Domain layer\n  - /etc/x\n"))
+	assert.ErrorAs(t, err, &AbsolutePathError{})
+
+	_, err = NewParser().ParseDependencyContent(strings.NewReader("## Shared\n- ../outside\n"))
+	assert.ErrorAs(t, err, &PathTraversalError{})
+}
@@ -0,0 +1,114 @@
+package dependency
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Style selects an architecture preset with `## Style: <name>` in DEPENDENCY.md
+type Style string
+
+func (s Style) String() string { return string(s) }
+
+const (
+	// StyleHexagonal is the ports and adapters architecture
+	StyleHexagonal Style = "hexagonal"
+)
+
+// presets maps each style to the layers it defines
+var presets = map[Style][]Layer{
+	StyleHexagonal: {
+		{Name: LayerName("Domain"), Order: 1},
+		{Name: LayerName("Ports"), Order: 2, Isolated: true},
+		{Name: LayerName("Adapters"), Order: 3, Isolated: true},
+	},
+}
+
+// GetPresetLayers returns a fresh copy of the layers defined by a style
+func GetPresetLayers(style Style) ([]Layer, error) {
+	preset, ok := presets[style]
+	if !ok {
+		return nil, UnknownStyleError{Style: style.String()}
+	}
+
+	layers := make([]Layer, len(preset))
+	for i, layer := range preset {
+		layers[i] = Layer{
+			Name:     layer.Name,
+			Order:    layer.Order,
+			Isolated: layer.Isolated,
+			Packages: make([]Package, 0),
+		}
+	}
+	return layers, nil
+}
+
+// ApplyStyle switches the config to a preset. Layers that were already
+// parsed must match the ones the preset defines.
+func (dc *DependencyConfig) ApplyStyle(style Style) error {
+	layers, err := GetPresetLayers(style)
+	if err != nil {
+		return err
+	}
+
+	if len(dc.Layers) == 0 {
+		dc.Style = style
+		dc.Layers = layers
+		return nil
+	}
+
+	for i := range dc.Layers {
+		preset := findLayer(layers, dc.Layers[i].Name, dc.Layers[i].Order)
+		if preset == nil {
+			return fmt.Errorf("layer %q is not defined by the %s style", dc.Layers[i].Name, style)
+		}
+		dc.Layers[i].Isolated = preset.Isolated
+	}
+	if len(dc.Layers) != len(layers) {
+		return fmt.Errorf("the %s style requires layers %s", style, describeLayers(layers))
+	}
+
+	dc.Style = style
+	return nil
+}
+
+// ValidateStyle checks that package placement follows the selected preset
+func (dc *DependencyConfig) ValidateStyle() error {
+	if dc.Style == "" {
+		return nil
+	}
+
+	seen := make(map[LayerPath]LayerName)
+	for _, layer := range dc.Layers {
+		for _, pkg := range layer.Packages {
+			if other, ok := seen[pkg.Path]; ok {
+				return fmt.Errorf("package %s is placed in both %q and %q", pkg.Path, other, layer.Name)
+			}
+			seen[pkg.Path] = layer.Name
+
+			// Packages in an isolated layer never depend on each other,
+			// so nesting would describe an ordering the style forbids
+			if layer.Isolated && pkg.Level > 0 {
+				return fmt.Errorf("package %s cannot be nested: packages in %q cannot depend on each other in the %s style", pkg.Path, layer.Name, dc.Style)
+			}
+		}
+	}
+	return nil
+}
+
+func findLayer(layers []Layer, name LayerName, order int) *Layer {
+	for i := range layers {
+		if layers[i].Name == name && layers[i].Order == order {
+			return &layers[i]
+		}
+	}
+	return nil
+}
+
+func describeLayers(layers []Layer) string {
+	names := make([]string, len(layers))
+	for i, layer := range layers {
+		names[i] = fmt.Sprintf("%d. %s", layer.Order, layer.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,132 @@
+package dependency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPresetLayers(t *testing.T) {
+	layers, err := GetPresetLayers(StyleHexagonal)
+	require.NoError(t, err)
+
+	require.Len(t, layers, 3)
+	assert.Equal(t, LayerName("Domain"), layers[0].Name)
+	assert.False(t, layers[0].Isolated)
+	assert.Equal(t, LayerName("Ports"), layers[1].Name)
+	assert.True(t, layers[1].Isolated)
+	assert.Equal(t, LayerName("Adapters"), layers[2].Name)
+	assert.True(t, layers[2].Isolated)
+
+	// Returned layers must not share state with the preset
+	layers[0].Packages = append(layers[0].Packages, Package{Path: LayerPath("domain")})
+	again, err := GetPresetLayers(StyleHexagonal)
+	require.NoError(t, err)
+	assert.Empty(t, again[0].Packages)
+
+	_, err = GetPresetLayers(Style("unknown"))
+	assert.Equal(t, UnknownStyleError{Style: "unknown"}, err)
+}
+
+func TestDependencyConfig_ApplyStyle(t *testing.T) {
+	tests := []struct {
+		name        string
+		layers      []Layer
+		expectError bool
+	}{
+		{
+			name:   "no layers yet",
+			layers: nil,
+		},
+		{
+			name: "matching layers",
+			layers: []Layer{
+				{Name: LayerName("Domain"), Order: 1},
+				{Name: LayerName("Ports"), Order: 2},
+				{Name: LayerName("Adapters"), Order: 3},
+			},
+		},
+		{
+			name: "unknown layer",
+			layers: []Layer{
+				{Name: LayerName("Domain"), Order: 1},
+				{Name: LayerName("Infra"), Order: 2},
+			},
+			expectError: true,
+		},
+		{
+			name: "missing layer",
+			layers: []Layer{
+				{Name: LayerName("Domain"), Order: 1},
+				{Name: LayerName("Ports"), Order: 2},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &DependencyConfig{Layers: tt.layers}
+			err := config.ApplyStyle(StyleHexagonal)
+
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, StyleHexagonal, config.Style)
+			require.Len(t, config.Layers, 3)
+			assert.True(t, config.Layers[1].Isolated)
+			assert.True(t, config.Layers[2].Isolated)
+		})
+	}
+}
+
+func TestDependencyConfig_ValidateStyle(t *testing.T) {
+	tests := []struct {
+		name        string
+		adapters    []Package
+		expectError bool
+	}{
+		{
+			name: "flat adapters",
+			adapters: []Package{
+				{Path: LayerPath("adapters/http"), Level: 0},
+				{Path: LayerPath("adapters/postgres"), Level: 0},
+			},
+		},
+		{
+			name: "nested adapter",
+			adapters: []Package{
+				{Path: LayerPath("adapters/http"), Level: 0},
+				{Path: LayerPath("adapters/postgres"), Level: 1},
+			},
+			expectError: true,
+		},
+		{
+			name: "package placed twice",
+			adapters: []Package{
+				{Path: LayerPath("domain"), Level: 0},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &DependencyConfig{}
+			require.NoError(t, config.ApplyStyle(StyleHexagonal))
+			config.Layers[0].Packages = []Package{{Path: LayerPath("domain"), Level: 0}}
+			config.Layers[2].Packages = tt.adapters
+
+			err := config.ValidateStyle()
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,633 @@
+package dependency
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Rule IDs identify the kind of a finding in machine-readable reports.
+// They are part of the report formats and must not change.
+const (
+	RuleParseError     = "parse-error"
+	RuleInvalidConfig  = "invalid-config"
+	RuleMissingPackage = "missing-package"
+	RuleStaleFile      = "stale-file"
+	RuleLayerViolation = "layer-violation"
+	RuleExternalImport = "external-import"
+	RuleSharedImport   = "shared-import"
+	RuleBannedSymbol   = "banned-symbol"
+	RuleBaselineFixed  = "baseline-fixed"
+	RuleError          = "error"
+
+	RuleInvalidSuppression = "invalid-suppression"
+	RuleExpiredSuppression = "expired-suppression"
+	RuleUnusedSuppression  = "unused-suppression"
+)
+
+// rules describes every rule ID, in the order reports list them, with the
+// severity of its findings unless DEPENDENCY.md sets another one
+var rules = []struct {
+	id           string
+	description  string
+	severity     Severity
+	configurable bool
+}{
+	{RuleParseError, "DEPENDENCY.md cannot be parsed", SeverityError, true},
+	{RuleInvalidConfig, "DEPENDENCY.md lists rules or package paths that are not valid", SeverityError, true},
+	{RuleMissingPackage, "A listed package directory does not exist or has no Go files", SeverityError, true},
+	{RuleStaleFile, "A generated file is out of date with DEPENDENCY.md", SeverityError, true},
+	{RuleLayerViolation, "A package imports a package that its layer may not depend on", SeverityError, true},
+	{RuleExternalImport, "A package imports a path outside the module that DEPENDENCY.md does not allow", SeverityError, true},
+	{RuleSharedImport, "A shared package imports a path outside the standard library", SeverityError, true},
+	{RuleBannedSymbol, "A package uses a banned symbol", SeverityError, true},
+	{RuleBaselineFixed, "A violation recorded in the baseline no longer occurs", SeverityInfo, true},
+	{RuleInvalidSuppression, "A //depcheck:ignore comment has no reason or a malformed argument", SeverityError, true},
+	{RuleExpiredSuppression, "A //depcheck:ignore comment is past its until date", SeverityError, true},
+	{RuleUnusedSuppression, "A //depcheck:ignore comment suppresses no violation", SeverityError, true},
+	{RuleError, "The tool failed to run", SeverityError, false},
+}
+
+// Finding is a single problem in a report
+type Finding struct {
+	RuleID   string   `json:"ruleId"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	File     string   `json:"file,omitempty"`   // Slash-separated, empty when the finding has no location
+	Line     int      `json:"line,omitempty"`   // 1-based, 0 when unknown
+	Column   int      `json:"column,omitempty"` // 1-based, 0 when unknown
+
+	// pinned is set when a layer, scope or rule of DEPENDENCY.md chose the
+	// severity, which the Severities section then does not change
+	pinned bool
+}
+
+// location returns the position of the finding as file:line:column
+func (f Finding) location() string {
+	location := f.File
+	if f.Line > 0 {
+		location += fmt.Sprintf(":%d", f.Line)
+		if f.Column > 0 {
+			location += fmt.Sprintf(":%d", f.Column)
+		}
+	}
+	return location
+}
+
+func (f Finding) String() string {
+	if f.File == "" {
+		return fmt.Sprintf("%s [%s]", f.Message, f.RuleID)
+	}
+	return fmt.Sprintf("%s: %s [%s]", f.location(), f.Message, f.RuleID)
+}
+
+// ViolationFindings returns a finding for every import violation
+func ViolationFindings(violations []ImportViolation) []Finding {
+	findings := make([]Finding, len(violations))
+	for i, violation := range violations {
+		findings[i] = Finding{
+			RuleID:   violation.RuleID(),
+			Severity: cmp.Or(violation.Severity, DefaultSeverity(violation.RuleID())),
+			Message:  violation.Message(),
+			File:     filepath.ToSlash(violation.File),
+			Line:     violation.Line,
+			Column:   violation.Column,
+			pinned:   violation.Severity != "",
+		}
+	}
+	return findings
+}
+
+// DiffFindings returns a finding for every generated file that is out of
+// date. baseDir is prepended to the file names.
+func DiffFindings(baseDir string, diffs []FileDiff) []Finding {
+	findings := make([]Finding, len(diffs))
+	for i, diff := range diffs {
+		finding := Finding{
+			RuleID:   RuleStaleFile,
+			Severity: DefaultSeverity(RuleStaleFile),
+			File:     path.Join(filepath.ToSlash(baseDir), diff.Name),
+		}
+		switch {
+		case diff.Old == nil:
+			finding.Message = fmt.Sprintf("%s is missing", diff.Name)
+		case diff.New == nil:
+			finding.Message = fmt.Sprintf("%s is no longer generated and should be deleted", diff.Name)
+		default:
+			finding.Message = fmt.Sprintf("%s is out of date", diff.Name)
+			finding.Line = firstChangedLine(diff.Old, diff.New)
+		}
+		findings[i] = finding
+	}
+	return findings
+}
+
+// firstChangedLine returns the 1-based line where old and new start to differ
+func firstChangedLine(old, new []byte) int {
+	line := 1
+	for i := 0; i < len(old) && i < len(new) && old[i] == new[i]; i++ {
+		if old[i] == '\n' {
+			line++
+		}
+	}
+	return line
+}
+
+// ErrorFindings returns a finding for every error in err, which may be a
+// GenerateError or errors.Join of several. dependencyFile is the path of
+// DEPENDENCY.md that findings about the config point to, and config, when
+// not nil, supplies the lines packages are listed on.
+func ErrorFindings(config *DependencyConfig, dependencyFile string, err error) []Finding {
+	var findings []Finding
+	for _, err := range flattenErrors(err) {
+		finding := Finding{
+			RuleID:  RuleError,
+			Message: err.Error(),
+		}
+
+		var (
+			parseErr      ParseError
+			validationErr ValidationError
+			missingErr    MissingPackageError
+			absoluteErr   AbsolutePathError
+			traversalErr  PathTraversalError
+			elementErr    InvalidPathElementError
+		)
+		switch {
+		case errors.As(err, &parseErr):
+			finding.RuleID = RuleParseError
+			finding.Message = parseErr.Err.Error()
+			finding.File = dependencyFile
+			finding.Line = parseErr.Line
+		case errors.As(err, &validationErr):
+			finding.RuleID = RuleInvalidConfig
+			finding.File = dependencyFile
+		case errors.As(err, &missingErr):
+			finding.RuleID = RuleMissingPackage
+			finding.File = dependencyFile
+			if config != nil {
+				finding.Line = config.PackageLine(LayerPath(missingErr.Path))
+			}
+		case errors.As(err, &absoluteErr), errors.As(err, &traversalErr), errors.As(err, &elementErr):
+			finding.RuleID = RuleInvalidConfig
+			finding.File = dependencyFile
+		}
+		finding.Severity = DefaultSeverity(finding.RuleID)
+
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// flattenErrors returns the errors joined in err, or err itself
+func flattenErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, err := range joined.Unwrap() {
+		errs = append(errs, flattenErrors(err)...)
+	}
+	return errs
+}
+
+// Format is an encoding of findings for other tools
+type Format string
+
+const (
+	FormatJSON       Format = "json"
+	FormatSARIF      Format = "sarif"
+	FormatJUnit      Format = "junit"
+	FormatCheckstyle Format = "checkstyle"
+	FormatGitHub     Format = "github"
+	FormatGitLab     Format = "gitlab"
+)
+
+// Formats lists every report format
+var Formats = []Format{FormatJSON, FormatSARIF, FormatJUnit, FormatCheckstyle, FormatGitHub, FormatGitLab}
+
+// UnknownFormatError is returned for a report format that does not exist
+type UnknownFormatError struct {
+	Format Format
+}
+
+func (e UnknownFormatError) Error() string {
+	return fmt.Sprintf("unknown report format %q", e.Format)
+}
+
+// WriteReport encodes findings to w in the given format
+func WriteReport(w io.Writer, format Format, findings []Finding) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case FormatJSON:
+		data, err = encodeJSON(findings)
+	case FormatSARIF:
+		data, err = encodeSARIF(findings)
+	case FormatJUnit:
+		data, err = encodeJUnit(findings)
+	case FormatCheckstyle:
+		data, err = encodeCheckstyle(findings)
+	case FormatGitHub:
+		data, err = encodeGitHub(findings)
+	case FormatGitLab:
+		data, err = encodeGitLab(findings)
+	default:
+		return UnknownFormatError{Format: format}
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func marshalJSON(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func marshalXML(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(xml.Header), data...), '\n'), nil
+}
+
+func encodeJSON(findings []Finding) ([]byte, error) {
+	report := struct {
+		Findings []Finding `json:"findings"`
+	}{
+		Findings: append([]Finding{}, findings...),
+	}
+	return marshalJSON(report)
+}
+
+// SARIF 2.1.0, as read by GitHub code scanning and other dashboards
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// sarifLevel maps a severity to a SARIF result level
+func sarifLevel(severity Severity) string {
+	switch severity {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+// sarifURI returns the artifact URI of a slash-separated file path
+func sarifURI(file string) string {
+	uri := url.URL{Path: file}
+	if path.IsAbs(file) {
+		uri.Scheme = "file"
+	}
+	return uri.String()
+}
+
+func encodeSARIF(findings []Finding) ([]byte, error) {
+	driver := sarifDriver{
+		Name:           "go-package-dependency",
+		InformationURI: "https://github.com/handlename/go-package-dependency",
+	}
+	for _, rule := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{ID: rule.id, ShortDescription: sarifMessage{Text: rule.description}})
+	}
+
+	results := []sarifResult{}
+	for _, finding := range findings {
+		result := sarifResult{
+			RuleID:  finding.RuleID,
+			Level:   sarifLevel(finding.Severity),
+			Message: sarifMessage{Text: finding.Message},
+		}
+		if finding.File != "" {
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifURI(finding.File)},
+			}}
+			if finding.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: finding.Line, StartColumn: finding.Column}
+			}
+			result.Locations = []sarifLocation{location}
+		}
+		results = append(results, result)
+	}
+
+	return marshalJSON(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
+
+// groupByFile returns the findings of each file, in the order files first appear
+func groupByFile(findings []Finding) ([]string, map[string][]Finding) {
+	var files []string
+	groups := map[string][]Finding{}
+	for _, finding := range findings {
+		if _, ok := groups[finding.File]; !ok {
+			files = append(files, finding.File)
+		}
+		groups[finding.File] = append(groups[finding.File], finding)
+	}
+	return files, groups
+}
+
+// JUnit XML, one test suite per file and one failed test case per finding
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string       `xml:"name,attr"`
+	ClassName string       `xml:"classname,attr"`
+	Failure   junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func encodeJUnit(findings []Finding) ([]byte, error) {
+	report := junitTestSuites{
+		Name:     "go-package-dependency",
+		Tests:    len(findings),
+		Failures: len(findings),
+	}
+
+	files, groups := groupByFile(findings)
+	for _, file := range files {
+		suite := junitTestSuite{
+			Name:     file,
+			Tests:    len(groups[file]),
+			Failures: len(groups[file]),
+		}
+		if suite.Name == "" {
+			suite.Name = "go-package-dependency"
+		}
+		for _, finding := range groups[file] {
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      finding.RuleID,
+				ClassName: finding.location(),
+				Failure: junitFailure{
+					Message: finding.Message,
+					Type:    string(finding.Severity),
+					Text:    finding.String(),
+				},
+			})
+		}
+		report.Suites = append(report.Suites, suite)
+	}
+
+	return marshalXML(report)
+}
+
+// Checkstyle XML, as read by Jenkins warnings and review bots
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr,omitempty"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+func encodeCheckstyle(findings []Finding) ([]byte, error) {
+	report := checkstyleReport{Version: "5.0"}
+
+	files, groups := groupByFile(findings)
+	for _, file := range files {
+		entry := checkstyleFile{Name: file}
+		for _, finding := range groups[file] {
+			entry.Errors = append(entry.Errors, checkstyleError{
+				Line:     finding.Line,
+				Column:   finding.Column,
+				Severity: string(finding.Severity),
+				Message:  finding.Message,
+				Source:   "go-package-dependency." + finding.RuleID,
+			})
+		}
+		report.Files = append(report.Files, entry)
+	}
+
+	return marshalXML(report)
+}
+
+// GitHub Actions workflow commands, which annotate the files of a pull request
+
+var (
+	githubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// githubCommand maps a severity to the workflow command that annotates it
+func githubCommand(severity Severity) string {
+	switch severity {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "notice"
+	default:
+		return "error"
+	}
+}
+
+func encodeGitHub(findings []Finding) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, finding := range findings {
+		var properties []string
+		if finding.File != "" {
+			properties = append(properties, "file="+githubPropertyEscaper.Replace(finding.File))
+			if finding.Line > 0 {
+				properties = append(properties, "line="+strconv.Itoa(finding.Line))
+			}
+			if finding.Column > 0 {
+				properties = append(properties, "col="+strconv.Itoa(finding.Column))
+			}
+		}
+		properties = append(properties, "title="+githubPropertyEscaper.Replace(finding.RuleID))
+
+		fmt.Fprintf(&buf, "::%s %s::%s\n", githubCommand(finding.Severity), strings.Join(properties, ","), githubDataEscaper.Replace(finding.Message))
+	}
+	return buf.Bytes(), nil
+}
+
+// GitLab Code Quality, which shows findings in merge request widgets and diffs
+
+type gitlabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    gitlabLocation `json:"location"`
+}
+
+type gitlabLocation struct {
+	Path  string      `json:"path"`
+	Lines gitlabLines `json:"lines"`
+}
+
+type gitlabLines struct {
+	Begin int `json:"begin"`
+}
+
+// gitlabSeverity maps a severity to a Code Quality severity
+func gitlabSeverity(severity Severity) string {
+	switch severity {
+	case SeverityWarning:
+		return "minor"
+	case SeverityInfo:
+		return "info"
+	default:
+		return "major"
+	}
+}
+
+// fingerprints identifies each finding by its rule, file and message, and
+// by how many identical findings come before it. Line numbers are left out
+// so that a finding keeps its fingerprint when code above it moves.
+func fingerprints(findings []Finding) []string {
+	seen := map[string]int{}
+	result := make([]string, len(findings))
+	for i, finding := range findings {
+		key := fingerprintKey(finding.RuleID, finding.File, finding.Message)
+		result[i] = fingerprint(key, seen[key])
+		seen[key]++
+	}
+	return result
+}
+
+// fingerprintKey joins the parts that identify a finding
+func fingerprintKey(parts ...string) string {
+	return strings.Join(parts, "\x00")
+}
+
+// fingerprint hashes a key and the number of findings with the same key before it
+func fingerprint(key string, occurrence int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", key, occurrence)))
+	return hex.EncodeToString(sum[:16])
+}
+
+func encodeGitLab(findings []Finding) ([]byte, error) {
+	issues := []gitlabIssue{}
+	for i, fingerprint := range fingerprints(findings) {
+		finding := findings[i]
+		issues = append(issues, gitlabIssue{
+			Description: finding.Message,
+			CheckName:   finding.RuleID,
+			Fingerprint: fingerprint,
+			Severity:    gitlabSeverity(finding.Severity),
+			Location: gitlabLocation{
+				Path:  finding.File,
+				Lines: gitlabLines{Begin: max(finding.Line, 1)},
+			},
+		})
+	}
+	return marshalJSON(issues)
+}
@@ -0,0 +1,140 @@
+package dependency
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reportGoldenDir = "testdata/report"
+
+// reportFindings covers every kind of location and characters that need escaping
+var reportFindings = []Finding{
+	{RuleID: RuleParseError, Severity: SeverityError, Message: `invalid package path: path "../x" leaves the module`, File: "DEPENDENCY.md", Line: 12},
+	{RuleID: RuleMissingPackage, Severity: SeverityError, Message: "package directory domian/entity does not exist (did you mean domain/entity?)", File: "DEPENDENCY.md", Line: 20},
+	{RuleID: RuleStaleFile, Severity: SeverityError, Message: "app/usecase/dependency.gen.go is out of date", File: "app/usecase/dependency.gen.go", Line: 6},
+	{RuleID: RuleLayerViolation, Severity: SeverityError, Message: "domain/entity must not import example.com/app/infra/db", File: "domain/entity/user.go", Line: 5, Column: 2},
+	{RuleID: RuleBannedSymbol, Severity: SeverityWarning, Message: "domain/entity must not use time.Now (use <clock> & friends)", File: "domain/entity/user.go", Line: 14, Column: 9},
+	{RuleID: RuleExternalImport, Severity: SeverityInfo, Message: "app/usecase must not import github.com/x/y (50% rollout,\nsee docs: DEPS)", File: "app/use,case/a:b.go", Line: 3, Column: 2},
+	{RuleID: RuleError, Severity: SeverityError, Message: "go.mod not found"},
+}
+
+func TestWriteReport_Golden(t *testing.T) {
+	extensions := map[Format]string{
+		FormatJSON:       "json",
+		FormatSARIF:      "sarif",
+		FormatJUnit:      "junit.xml",
+		FormatCheckstyle: "checkstyle.xml",
+		FormatGitHub:     "github.txt",
+		FormatGitLab:     "gitlab.json",
+	}
+
+	for _, format := range Formats {
+		for name, findings := range map[string][]Finding{"findings": reportFindings, "empty": nil} {
+			t.Run(string(format)+"/"+name, func(t *testing.T) {
+				var buf bytes.Buffer
+				require.NoError(t, WriteReport(&buf, format, findings))
+
+				golden := filepath.Join(reportGoldenDir, name+"."+extensions[format])
+				if os.Getenv("UPDATE_GOLDEN") != "" {
+					require.NoError(t, os.MkdirAll(reportGoldenDir, 0755))
+					require.NoError(t, os.WriteFile(golden, buf.Bytes(), 0644))
+				}
+
+				expected, err := os.ReadFile(golden)
+				require.NoError(t, err)
+				assert.Equal(t, string(expected), buf.String())
+			})
+		}
+	}
+}
+
+func TestFingerprints(t *testing.T) {
+	finding := Finding{RuleID: RuleBannedSymbol, File: "domain/entity/user.go", Line: 14, Message: "domain/entity must not use time.Now"}
+	moved := finding
+	moved.Line = 20
+
+	first := fingerprints([]Finding{finding, finding})
+	second := fingerprints([]Finding{moved, finding})
+
+	assert.NotEqual(t, first[0], first[1], "identical findings get distinct fingerprints")
+	assert.Equal(t, first, second, "fingerprints do not depend on line numbers")
+}
+
+func TestWriteReport_UnknownFormat(t *testing.T) {
+	err := WriteReport(&bytes.Buffer{}, Format("yaml"), nil)
+	assert.Equal(t, UnknownFormatError{Format: "yaml"}, err)
+}
+
+func TestErrorFindings(t *testing.T) {
+	config, err := NewParser().ParseDependencyContent(strings.NewReader(`# Dependencies
+
+## Layers
+
+1. Domain
+
+## Packages in layers
+
+1. Domain
+  - domain/entity
+  - domain/service
+`))
+	require.NoError(t, err)
+
+	generateErr := GenerateError{Errors: []error{
+		MissingPackageError{Path: "domain/service", Exists: true},
+		errors.New("permission denied"),
+	}}
+	assert.Equal(t, []Finding{
+		{RuleID: RuleMissingPackage, Severity: SeverityError, Message: "package directory domain/service has no Go files", File: "DEPENDENCY.md", Line: 11},
+		{RuleID: RuleError, Severity: SeverityError, Message: "permission denied"},
+	}, ErrorFindings(config, "DEPENDENCY.md", generateErr))
+
+	_, err = NewParser().ParseDependencyContent(strings.NewReader("## Packages in layers\n\n1. \n"))
+	require.Error(t, err)
+	assert.Equal(t, []Finding{
+		{RuleID: RuleParseError, Severity: SeverityError, Message: "invalid layer name: layer name cannot be empty", File: "DEPENDENCY.md", Line: 3},
+	}, ErrorFindings(nil, "DEPENDENCY.md", err))
+
+	_, err = NewParser().ParseDependencyContent(strings.NewReader("## Shared\n- pkg/errors\n- pkg/errors\n"))
+	require.Error(t, err)
+	assert.Equal(t, []Finding{
+		{RuleID: RuleInvalidConfig, Severity: SeverityError, Message: "shared package pkg/errors is listed more than once", File: "DEPENDENCY.md"},
+	}, ErrorFindings(nil, "DEPENDENCY.md", err))
+}
+
+func TestDiffFindings(t *testing.T) {
+	diffs := []FileDiff{
+		{Name: "app/dependency.gen.go", New: []byte("package app\n")},
+		{Name: "domain/dependency.gen.go", Old: []byte("package domain\n\nimport _ \"a\"\n"), New: []byte("package domain\n\nimport _ \"b\"\n")},
+		{Name: "infra/dependency.gen.go", Old: []byte("package infra\n")},
+	}
+
+	assert.Equal(t, []Finding{
+		{RuleID: RuleStaleFile, Severity: SeverityError, Message: "app/dependency.gen.go is missing", File: "example/app/dependency.gen.go"},
+		{RuleID: RuleStaleFile, Severity: SeverityError, Message: "domain/dependency.gen.go is out of date", File: "example/domain/dependency.gen.go", Line: 3},
+		{RuleID: RuleStaleFile, Severity: SeverityError, Message: "infra/dependency.gen.go is no longer generated and should be deleted", File: "example/infra/dependency.gen.go"},
+	}, DiffFindings("example", diffs))
+}
+
+func TestImportViolation_RuleID(t *testing.T) {
+	tests := []struct {
+		violation ImportViolation
+		expected  string
+	}{
+		{ImportViolation{Imported: "infra/db"}, RuleLayerViolation},
+		{ImportViolation{Reason: "only github.com/google/uuid is allowed"}, RuleExternalImport},
+		{ImportViolation{Reason: sharedImportReason}, RuleSharedImport},
+		{ImportViolation{Symbol: "time.Now", Reason: "use the clock"}, RuleBannedSymbol},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.violation.RuleID())
+	}
+}
@@ -0,0 +1,275 @@
+package dependency
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// stdPattern matches every standard library import path
+const stdPattern = "std"
+
+// PackagePattern matches listed package paths. It is either a path.Match
+// glob like "domain/*" or a path ending in "/..." that also matches every
+// package below it.
+type PackagePattern string
+
+func (pp PackagePattern) String() string { return string(pp) }
+
+func (pp PackagePattern) Match(packagePath LayerPath) bool {
+	return matchPattern(string(pp), string(packagePath))
+}
+
+func (pp PackagePattern) Validate() error {
+	if strings.TrimSpace(string(pp)) == "" {
+		return fmt.Errorf("package pattern cannot be empty")
+	}
+	if _, err := path.Match(string(pp), ""); err != nil {
+		return fmt.Errorf("package pattern %q is malformed", pp)
+	}
+	return nil
+}
+
+// ImportPattern matches import paths from outside the module. Besides the
+// forms of PackagePattern, "std" matches the whole standard library.
+type ImportPattern string
+
+func (ip ImportPattern) String() string { return string(ip) }
+
+func (ip ImportPattern) Match(importPath string) bool {
+	if ip == stdPattern {
+		return IsStandardLibrary(importPath)
+	}
+	return matchPattern(string(ip), importPath)
+}
+
+func (ip ImportPattern) Validate() error {
+	if strings.TrimSpace(string(ip)) == "" {
+		return fmt.Errorf("import pattern cannot be empty")
+	}
+	if _, err := path.Match(string(ip), ""); err != nil {
+		return fmt.Errorf("import pattern %q is malformed", ip)
+	}
+	return nil
+}
+
+func matchPattern(pattern string, value string) bool {
+	if base, ok := strings.CutSuffix(pattern, "/..."); ok {
+		return value == base || strings.HasPrefix(value, base+"/")
+	}
+	matched, err := path.Match(pattern, value)
+	return err == nil && matched
+}
+
+// RuleScope selects the packages a rule applies to, either every package
+// in a layer or every package matching a pattern
+type RuleScope struct {
+	Layer   LayerName      // Set for layer scopes
+	Order   int            // Order of the layer for layer scopes
+	Pattern PackagePattern // Set for package scopes
+
+	Severity Severity // Severity of the rules below the scope, empty for the rule's
+}
+
+func (rs RuleScope) String() string {
+	if rs.Layer != "" {
+		return fmt.Sprintf("%d. %s", rs.Order, rs.Layer)
+	}
+	return rs.Pattern.String()
+}
+
+// Contains reports whether the package falls in the scope
+func (rs RuleScope) Contains(config *DependencyConfig, pkg Package) bool {
+	if rs.Layer == "" {
+		return rs.Pattern.Match(pkg.Path)
+	}
+	layer := config.FindLayer(pkg.Path)
+	return layer != nil && layer.Name == rs.Layer && layer.Order == rs.Order
+}
+
+// ExternalImportRule allows or forbids imports from outside the module
+type ExternalImportRule struct {
+	Scope    RuleScope
+	Allow    bool
+	Pattern  ImportPattern
+	Severity Severity // Severity of the imports it refuses, empty for the rule's
+}
+
+func (r ExternalImportRule) String() string {
+	action := "forbid"
+	if r.Allow {
+		action = "allow"
+	}
+	return fmt.Sprintf("%s: %s %s", r.Scope, action, r.Pattern)
+}
+
+// CheckExternalImport decides whether a package may import a path from
+// outside the module. The last matching rule wins. When no rule matches,
+// the import is allowed unless an allow rule applies to the package, which
+// turns its rules into an allowlist. The returned rule explains a refusal.
+func (dc *DependencyConfig) CheckExternalImport(pkg Package, importPath string) (bool, *ExternalImportRule) {
+	var (
+		matched   *ExternalImportRule
+		allowlist *ExternalImportRule
+	)
+
+	for i := range dc.ExternalImports {
+		rule := &dc.ExternalImports[i]
+		if !rule.Scope.Contains(dc, pkg) {
+			continue
+		}
+		if rule.Allow && allowlist == nil {
+			allowlist = rule
+		}
+		if rule.Pattern.Match(importPath) {
+			matched = rule
+		}
+	}
+
+	if matched != nil {
+		return matched.Allow, matched
+	}
+	if allowlist != nil {
+		return false, allowlist
+	}
+	return true, nil
+}
+
+// BannedSymbol is a package-level identifier of an imported package,
+// written as "<import path>.<name>" like "time.Now" or "math/rand.Int"
+type BannedSymbol struct {
+	ImportPath string
+	Name       string
+}
+
+func ParseBannedSymbol(s string) (BannedSymbol, error) {
+	s = strings.TrimSpace(s)
+	index := strings.LastIndex(s, ".")
+	if index <= 0 || index == len(s)-1 || index < strings.LastIndex(s, "/") {
+		return BannedSymbol{}, fmt.Errorf("symbol %q must be written as <import path>.<name>", s)
+	}
+	return BannedSymbol{ImportPath: s[:index], Name: s[index+1:]}, nil
+}
+
+func (bs BannedSymbol) String() string {
+	return bs.ImportPath + "." + bs.Name
+}
+
+// BannedSymbolRule forbids the packages in a scope from using a symbol
+type BannedSymbolRule struct {
+	Scope    RuleScope
+	Symbol   BannedSymbol
+	Severity Severity // Severity of its uses, empty for the rule's
+}
+
+func (r BannedSymbolRule) String() string {
+	return fmt.Sprintf("%s: ban %s", r.Scope, r.Symbol)
+}
+
+// GetBannedSymbols returns the rules that apply to the package
+func (dc *DependencyConfig) GetBannedSymbols(pkg Package) []BannedSymbolRule {
+	var rules []BannedSymbolRule
+	for _, rule := range dc.BannedSymbols {
+		if rule.Scope.Contains(dc, pkg) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// TestMode decides which rules apply to _test.go files, selected with
+// `## Tests: <mode>` in DEPENDENCY.md
+type TestMode string
+
+func (tm TestMode) String() string { return string(tm) }
+
+const (
+	// TestModeProduction applies the production rules to tests, the default
+	TestModeProduction TestMode = "production"
+	// TestModeRelaxed lets tests import any package of the module
+	TestModeRelaxed TestMode = "relaxed"
+	// TestModeCustom adds the test import rules of the tests section
+	// to the production rules
+	TestModeCustom TestMode = "custom"
+)
+
+func (tm TestMode) Validate() error {
+	switch tm {
+	case TestModeProduction, TestModeRelaxed, TestModeCustom:
+		return nil
+	}
+	return fmt.Errorf("unknown test mode: %s", tm)
+}
+
+// TestImportRule allows test files of the packages in a scope to import
+// module packages that production code of the same packages cannot
+type TestImportRule struct {
+	Scope   RuleScope
+	Pattern PackagePattern
+}
+
+func (r TestImportRule) String() string {
+	return fmt.Sprintf("%s: allow %s", r.Scope, r.Pattern)
+}
+
+// IsTestDependencyAllowed reports whether test files of the importer package
+// may import the imported package
+func (dc *DependencyConfig) IsTestDependencyAllowed(importer Package, imported Package) bool {
+	switch dc.TestMode {
+	case TestModeRelaxed:
+		return true
+	case TestModeCustom:
+		for _, rule := range dc.TestImports {
+			if rule.Scope.Contains(dc, importer) && rule.Pattern.Match(imported.Path) {
+				return true
+			}
+		}
+	}
+	return dc.IsDependencyAllowed(importer, imported)
+}
+
+// GetTestDependenciesForPackage calculates the packages that test files of
+// the given package may depend on
+func (dc *DependencyConfig) GetTestDependenciesForPackage(targetPackage Package) []LayerPath {
+	if dc.TestMode != TestModeRelaxed && dc.TestMode != TestModeCustom {
+		return dc.GetDependenciesForPackage(targetPackage)
+	}
+
+	var dependencies []LayerPath
+	for _, pkg := range dc.GetAllPackages() {
+		if pkg.Path != targetPackage.Path && dc.IsTestDependencyAllowed(targetPackage, pkg) {
+			dependencies = append(dependencies, pkg.Path)
+		}
+	}
+	return dependencies
+}
+
+// ValidateRules checks that every layer scope refers to a defined layer
+func (dc *DependencyConfig) ValidateRules() error {
+	for _, rule := range dc.ExternalImports {
+		if err := dc.validateScope(rule.Scope); err != nil {
+			return fmt.Errorf("external import rule refers to %v", err)
+		}
+	}
+	for _, rule := range dc.BannedSymbols {
+		if err := dc.validateScope(rule.Scope); err != nil {
+			return fmt.Errorf("banned symbol refers to %v", err)
+		}
+	}
+	for _, rule := range dc.TestImports {
+		if err := dc.validateScope(rule.Scope); err != nil {
+			return fmt.Errorf("test import rule refers to %v", err)
+		}
+	}
+	return nil
+}
+
+func (dc *DependencyConfig) validateScope(scope RuleScope) error {
+	if scope.Layer == "" {
+		return nil
+	}
+	if findLayer(dc.Layers, scope.Layer, scope.Order) == nil {
+		return fmt.Errorf("undefined layer %q", scope)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package dependency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackagePattern_Match(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  PackagePattern
+		path     LayerPath
+		expected bool
+	}{
+		{"exact path", PackagePattern("domain/entity"), LayerPath("domain/entity"), true},
+		{"different path", PackagePattern("domain/entity"), LayerPath("domain/service"), false},
+		{"glob matches child", PackagePattern("domain/*"), LayerPath("domain/entity"), true},
+		{"glob does not match grandchild", PackagePattern("domain/*"), LayerPath("domain/entity/user"), false},
+		{"ellipsis matches itself", PackagePattern("domain/..."), LayerPath("domain"), true},
+		{"ellipsis matches grandchild", PackagePattern("domain/..."), LayerPath("domain/entity/user"), true},
+		{"ellipsis does not match shared prefix", PackagePattern("domain/..."), LayerPath("domainx"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.pattern.Match(tt.path))
+		})
+	}
+}
+
+func TestImportPattern_Match(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    ImportPattern
+		importPath string
+		expected   bool
+	}{
+		{"exact path", ImportPattern("net/http"), "net/http", true},
+		{"subpackage needs ellipsis", ImportPattern("net/http"), "net/http/httptest", false},
+		{"ellipsis matches subpackage", ImportPattern("github.com/jackc/pgx/..."), "github.com/jackc/pgx/v5/pgxpool", true},
+		{"std matches standard library", ImportPattern("std"), "encoding/json", true},
+		{"std does not match third party", ImportPattern("std"), "github.com/jackc/pgx", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.pattern.Match(tt.importPath))
+		})
+	}
+}
+
+func TestPatterns_Validate(t *testing.T) {
+	assert.NoError(t, PackagePattern("domain/*").Validate())
+	assert.Error(t, PackagePattern("").Validate())
+	assert.Error(t, PackagePattern("domain/[").Validate())
+
+	assert.NoError(t, ImportPattern("std").Validate())
+	assert.Error(t, ImportPattern(" ").Validate())
+	assert.Error(t, ImportPattern("net/[").Validate())
+}
+
+func TestRuleScope_Contains(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Infra layer"), Order: 2, Packages: []Package{{Path: LayerPath("infra/database")}}},
+		},
+	}
+
+	layerScope := RuleScope{Layer: LayerName("Domain layer"), Order: 1}
+	assert.True(t, layerScope.Contains(config, Package{Path: LayerPath("domain/entity")}))
+	assert.False(t, layerScope.Contains(config, Package{Path: LayerPath("infra/database")}))
+	assert.Equal(t, "1. Domain layer", layerScope.String())
+
+	patternScope := RuleScope{Pattern: PackagePattern("infra/*")}
+	assert.True(t, patternScope.Contains(config, Package{Path: LayerPath("infra/database")}))
+	assert.False(t, patternScope.Contains(config, Package{Path: LayerPath("domain/entity")}))
+	assert.Equal(t, "infra/*", patternScope.String())
+}
+
+func TestDependencyConfig_CheckExternalImport(t *testing.T) {
+	domain := RuleScope{Layer: LayerName("Domain layer"), Order: 1}
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Infra layer"), Order: 2, Packages: []Package{{Path: LayerPath("infra/database")}, {Path: LayerPath("infra/cache")}}},
+		},
+		ExternalImports: []ExternalImportRule{
+			{Scope: domain, Pattern: ImportPattern("github.com/jackc/pgx/...")},
+			{Scope: domain, Pattern: ImportPattern("net/http")},
+			{Scope: RuleScope{Pattern: PackagePattern("infra/database")}, Allow: true, Pattern: ImportPattern("std")},
+			{Scope: RuleScope{Pattern: PackagePattern("infra/database")}, Allow: true, Pattern: ImportPattern("github.com/jackc/pgx/...")},
+			{Scope: RuleScope{Pattern: PackagePattern("infra/database")}, Pattern: ImportPattern("net/http")},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		pkg        LayerPath
+		importPath string
+		allowed    bool
+		rule       string
+	}{
+		{"forbidden in domain", LayerPath("domain/entity"), "github.com/jackc/pgx/v5", false, "1. Domain layer: forbid github.com/jackc/pgx/..."},
+		{"unmatched in domain", LayerPath("domain/entity"), "fmt", true, ""},
+		{"allowed by allowlist", LayerPath("infra/database"), "github.com/jackc/pgx/v5", true, "infra/database: allow github.com/jackc/pgx/..."},
+		{"outside allowlist", LayerPath("infra/database"), "google.golang.org/grpc", false, "infra/database: allow std"},
+		{"later forbid wins", LayerPath("infra/database"), "net/http", false, "infra/database: forbid net/http"},
+		{"no rules", LayerPath("infra/cache"), "google.golang.org/grpc", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, rule := config.CheckExternalImport(Package{Path: tt.pkg}, tt.importPath)
+			assert.Equal(t, tt.allowed, allowed)
+			if tt.rule == "" {
+				assert.Nil(t, rule)
+			} else {
+				assert.Equal(t, tt.rule, rule.String())
+			}
+		})
+	}
+}
+
+func TestDependencyConfig_ValidateRules(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{{Name: LayerName("Domain layer"), Order: 1}},
+		ExternalImports: []ExternalImportRule{
+			{Scope: RuleScope{Layer: LayerName("Domain layer"), Order: 1}, Pattern: ImportPattern("net/http")},
+		},
+	}
+	assert.NoError(t, config.ValidateRules())
+
+	config.ExternalImports[0].Scope.Order = 2
+	assert.Error(t, config.ValidateRules())
+}
+
+func TestParseBannedSymbol(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    BannedSymbol
+		expectError bool
+	}{
+		{"time.Now", BannedSymbol{ImportPath: "time", Name: "Now"}, false},
+		{"math/rand.Int", BannedSymbol{ImportPath: "math/rand", Name: "Int"}, false},
+		{"gopkg.in/yaml.v3.Marshal", BannedSymbol{ImportPath: "gopkg.in/yaml.v3", Name: "Marshal"}, false},
+		{"time", BannedSymbol{}, true},
+		{"time.", BannedSymbol{}, true},
+		{".Now", BannedSymbol{}, true},
+		{"gopkg.in/yaml", BannedSymbol{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			symbol, err := ParseBannedSymbol(tt.input)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, symbol)
+			assert.Equal(t, tt.input, symbol.String())
+		})
+	}
+}
+
+func TestDependencyConfig_GetBannedSymbols(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Infra layer"), Order: 2, Packages: []Package{{Path: LayerPath("infra/database")}}},
+		},
+		BannedSymbols: []BannedSymbolRule{
+			{Scope: RuleScope{Layer: LayerName("Domain layer"), Order: 1}, Symbol: BannedSymbol{ImportPath: "time", Name: "Now"}},
+			{Scope: RuleScope{Pattern: PackagePattern("infra/*")}, Symbol: BannedSymbol{ImportPath: "os", Name: "Exit"}},
+		},
+	}
+
+	rules := config.GetBannedSymbols(Package{Path: LayerPath("domain/entity")})
+	assert.Len(t, rules, 1)
+	assert.Equal(t, "1. Domain layer: ban time.Now", rules[0].String())
+
+	rules = config.GetBannedSymbols(Package{Path: LayerPath("infra/database")})
+	assert.Len(t, rules, 1)
+	assert.Equal(t, "infra/*: ban os.Exit", rules[0].String())
+
+	config.BannedSymbols[0].Scope.Order = 3
+	assert.Error(t, config.ValidateRules())
+}
+
+func TestDependencyConfig_IsTestDependencyAllowed(t *testing.T) {
+	entity := Package{Path: LayerPath("domain/entity")}
+	usecase := Package{Path: LayerPath("app/usecase")}
+	fake := Package{Path: LayerPath("infra/fake")}
+
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{entity}},
+			{Name: LayerName("Application layer"), Order: 2, Packages: []Package{usecase}},
+			{Name: LayerName("Infra layer"), Order: 3, Packages: []Package{fake}},
+		},
+		TestImports: []TestImportRule{
+			{Scope: RuleScope{Pattern: PackagePattern("domain/...")}, Pattern: PackagePattern("infra/*")},
+		},
+	}
+
+	tests := []struct {
+		mode             TestMode
+		allowedFake      bool
+		allowedUsecase   bool
+		testDependencies []LayerPath
+	}{
+		{"", false, false, nil},
+		{TestModeProduction, false, false, nil},
+		{TestModeCustom, true, false, []LayerPath{LayerPath("infra/fake")}},
+		{TestModeRelaxed, true, true, []LayerPath{LayerPath("app/usecase"), LayerPath("infra/fake")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			config.TestMode = tt.mode
+			assert.Equal(t, tt.allowedFake, config.IsTestDependencyAllowed(entity, fake))
+			assert.Equal(t, tt.allowedUsecase, config.IsTestDependencyAllowed(entity, usecase))
+			assert.True(t, config.IsTestDependencyAllowed(usecase, entity))
+			assert.Equal(t, tt.testDependencies, config.GetTestDependenciesForPackage(entity))
+		})
+	}
+
+	assert.NoError(t, TestModeCustom.Validate())
+	assert.Error(t, TestMode("loose").Validate())
+}
@@ -0,0 +1,199 @@
+package dependency
+
+import (
+	"fmt"
+	"io"
+)
+
+// RuleDiff is the effect of a change to DEPENDENCY.md on what packages may import
+type RuleDiff struct {
+	Gained          []Permission  `json:"gained,omitempty"`
+	Lost            []Permission  `json:"lost,omitempty"`
+	AddedPackages   []PackageMove `json:"addedPackages,omitempty"`
+	RemovedPackages []PackageMove `json:"removedPackages,omitempty"`
+	MovedPackages   []PackageMove `json:"movedPackages,omitempty"`
+	AddedLayers     []LayerMove   `json:"addedLayers,omitempty"`
+	RemovedLayers   []LayerMove   `json:"removedLayers,omitempty"`
+	ReorderedLayers []LayerMove   `json:"reorderedLayers,omitempty"`
+}
+
+// Permission allows Importer to import Imported
+type Permission struct {
+	Importer LayerPath `json:"importer"`
+	Imported LayerPath `json:"imported"`
+}
+
+func (p Permission) String() string {
+	return fmt.Sprintf("%s -> %s", p.Importer, p.Imported)
+}
+
+// Placement is where a package is listed in DEPENDENCY.md
+type Placement struct {
+	Layer LayerName `json:"layer"` // Empty for shared packages
+	Level int       `json:"level"`
+}
+
+func (p Placement) String() string {
+	if p.Level == 0 {
+		return layerLabel(p.Layer)
+	}
+	return fmt.Sprintf("%s level %d", layerLabel(p.Layer), p.Level)
+}
+
+// PackageMove is a package that was added, removed or moved. From is nil
+// for added packages and To is nil for removed ones.
+type PackageMove struct {
+	Path LayerPath  `json:"path"`
+	From *Placement `json:"from,omitempty"`
+	To   *Placement `json:"to,omitempty"`
+}
+
+// LayerMove is a layer that was added, removed or reordered. OldOrder is 0
+// for added layers and NewOrder is 0 for removed ones.
+type LayerMove struct {
+	Name     LayerName `json:"name"`
+	OldOrder int       `json:"oldOrder,omitempty"`
+	NewOrder int       `json:"newOrder,omitempty"`
+}
+
+// DiffRules compares two versions of DEPENDENCY.md. Permissions are only
+// compared between packages listed in both versions, so that adding or
+// removing a package shows up once instead of once per package pair.
+func DiffRules(oldConfig, newConfig *DependencyConfig) *RuleDiff {
+	diff := &RuleDiff{}
+
+	oldLayers := make(map[LayerName]int)
+	for _, layer := range oldConfig.Layers {
+		oldLayers[layer.Name] = layer.Order
+	}
+	newLayers := make(map[LayerName]int)
+	for _, layer := range newConfig.Layers {
+		newLayers[layer.Name] = layer.Order
+		oldOrder, ok := oldLayers[layer.Name]
+		switch {
+		case !ok:
+			diff.AddedLayers = append(diff.AddedLayers, LayerMove{Name: layer.Name, NewOrder: layer.Order})
+		case oldOrder != layer.Order:
+			diff.ReorderedLayers = append(diff.ReorderedLayers, LayerMove{Name: layer.Name, OldOrder: oldOrder, NewOrder: layer.Order})
+		}
+	}
+	for _, layer := range oldConfig.Layers {
+		if _, ok := newLayers[layer.Name]; !ok {
+			diff.RemovedLayers = append(diff.RemovedLayers, LayerMove{Name: layer.Name, OldOrder: layer.Order})
+		}
+	}
+
+	oldPlacements := placements(oldConfig)
+	newPlacements := placements(newConfig)
+	for _, pkg := range newConfig.GetAllPackages() {
+		to := newPlacements[pkg.Path]
+		from, ok := oldPlacements[pkg.Path]
+		switch {
+		case !ok:
+			diff.AddedPackages = append(diff.AddedPackages, PackageMove{Path: pkg.Path, To: &to})
+		case from != to:
+			diff.MovedPackages = append(diff.MovedPackages, PackageMove{Path: pkg.Path, From: &from, To: &to})
+		}
+	}
+	for _, pkg := range oldConfig.GetAllPackages() {
+		if _, ok := newPlacements[pkg.Path]; !ok {
+			from := oldPlacements[pkg.Path]
+			diff.RemovedPackages = append(diff.RemovedPackages, PackageMove{Path: pkg.Path, From: &from})
+		}
+	}
+
+	inBoth := func(path LayerPath) bool {
+		_, inOld := oldPlacements[path]
+		_, inNew := newPlacements[path]
+		return inOld && inNew
+	}
+	oldPackages := make(map[LayerPath]Package)
+	for _, pkg := range oldConfig.GetAllPackages() {
+		oldPackages[pkg.Path] = pkg
+	}
+	for _, pkg := range newConfig.GetAllPackages() {
+		oldPkg, ok := oldPackages[pkg.Path]
+		if !ok {
+			continue
+		}
+		oldDeps := oldConfig.GetDependenciesForPackage(oldPkg)
+		newDeps := newConfig.GetDependenciesForPackage(pkg)
+		diff.Gained = append(diff.Gained, permissionsOnlyIn(pkg.Path, newDeps, oldDeps, inBoth)...)
+		diff.Lost = append(diff.Lost, permissionsOnlyIn(pkg.Path, oldDeps, newDeps, inBoth)...)
+	}
+
+	return diff
+}
+
+// placements returns where every package of the configuration is listed
+func placements(config *DependencyConfig) map[LayerPath]Placement {
+	result := make(map[LayerPath]Placement)
+	for _, pkg := range config.Shared {
+		result[pkg.Path] = Placement{Level: pkg.Level}
+	}
+	for _, layer := range config.Layers {
+		for _, pkg := range layer.Packages {
+			result[pkg.Path] = Placement{Layer: layer.Name, Level: pkg.Level}
+		}
+	}
+	return result
+}
+
+// permissionsOnlyIn returns the permissions of importer to the packages in
+// deps but not in others, skipping packages that keep does not accept
+func permissionsOnlyIn(importer LayerPath, deps, others []LayerPath, keep func(LayerPath) bool) []Permission {
+	seen := make(map[LayerPath]bool, len(others))
+	for _, dep := range others {
+		seen[dep] = true
+	}
+	var permissions []Permission
+	for _, dep := range deps {
+		if !seen[dep] && keep(dep) {
+			permissions = append(permissions, Permission{Importer: importer, Imported: dep})
+		}
+	}
+	return permissions
+}
+
+// IsEmpty reports whether both versions allow the same imports from the same layout
+func (d *RuleDiff) IsEmpty() bool {
+	return len(d.Gained) == 0 && len(d.Lost) == 0 &&
+		len(d.AddedPackages) == 0 && len(d.RemovedPackages) == 0 && len(d.MovedPackages) == 0 &&
+		len(d.AddedLayers) == 0 && len(d.RemovedLayers) == 0 && len(d.ReorderedLayers) == 0
+}
+
+// Write prints the diff for people, one change per line
+func (d *RuleDiff) Write(w io.Writer) error {
+	var lines []string
+	for _, layer := range d.AddedLayers {
+		lines = append(lines, fmt.Sprintf("+ layer %d. %s", layer.NewOrder, layer.Name))
+	}
+	for _, layer := range d.RemovedLayers {
+		lines = append(lines, fmt.Sprintf("- layer %d. %s", layer.OldOrder, layer.Name))
+	}
+	for _, layer := range d.ReorderedLayers {
+		lines = append(lines, fmt.Sprintf("~ layer %s: %d -> %d", layer.Name, layer.OldOrder, layer.NewOrder))
+	}
+	for _, pkg := range d.AddedPackages {
+		lines = append(lines, fmt.Sprintf("+ package %s (%s)", pkg.Path, pkg.To))
+	}
+	for _, pkg := range d.RemovedPackages {
+		lines = append(lines, fmt.Sprintf("- package %s (%s)", pkg.Path, pkg.From))
+	}
+	for _, pkg := range d.MovedPackages {
+		lines = append(lines, fmt.Sprintf("~ package %s: %s -> %s", pkg.Path, pkg.From, pkg.To))
+	}
+	for _, permission := range d.Gained {
+		lines = append(lines, "+ "+permission.String())
+	}
+	for _, permission := range d.Lost {
+		lines = append(lines, "- "+permission.String())
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
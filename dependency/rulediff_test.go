@@ -0,0 +1,114 @@
+package dependency
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ruleDiffOld = `## Layers
+
+1. Domain layer
+2. Application layer
+3. Infra layer
+
+## Packages in layers
+
+1. Domain layer
+  - domain/entity
+2. Application layer
+  - app/service
+    - app/usecase
+3. Infra layer
+  - infra/database
+  - infra/legacy
+`
+
+const ruleDiffNew = `## Layers
+
+1. Domain layer
+2. Application layer
+3. Presentation layer
+4. Infra layer
+
+## Packages in layers
+
+1. Domain layer
+  - domain/entity
+  - domain/service
+2. Application layer
+  - app/usecase
+  - app/service
+3. Presentation layer
+  - api
+4. Infra layer
+  - infra/database
+`
+
+func TestDiffRules(t *testing.T) {
+	oldConfig, err := NewParser().ParseDependencyContent(strings.NewReader(ruleDiffOld))
+	require.NoError(t, err)
+	newConfig, err := NewParser().ParseDependencyContent(strings.NewReader(ruleDiffNew))
+	require.NoError(t, err)
+
+	diff := DiffRules(oldConfig, newConfig)
+
+	assert.Equal(t, &RuleDiff{
+		Gained: []Permission{{Importer: "app/service", Imported: "app/usecase"}},
+		Lost:   []Permission{{Importer: "app/usecase", Imported: "app/service"}},
+		AddedPackages: []PackageMove{
+			{Path: "domain/service", To: &Placement{Layer: "Domain layer"}},
+			{Path: "api", To: &Placement{Layer: "Presentation layer"}},
+		},
+		RemovedPackages: []PackageMove{
+			{Path: "infra/legacy", From: &Placement{Layer: "Infra layer"}},
+		},
+		MovedPackages: []PackageMove{
+			{Path: "app/usecase", From: &Placement{Layer: "Application layer", Level: 1}, To: &Placement{Layer: "Application layer"}},
+		},
+		AddedLayers:     []LayerMove{{Name: "Presentation layer", NewOrder: 3}},
+		ReorderedLayers: []LayerMove{{Name: "Infra layer", OldOrder: 3, NewOrder: 4}},
+	}, diff)
+
+	var buf bytes.Buffer
+	require.NoError(t, diff.Write(&buf))
+	assert.Equal(t, `+ layer 3. Presentation layer
+~ layer Infra layer: 3 -> 4
++ package domain/service (Domain layer)
++ package api (Presentation layer)
+- package infra/legacy (Infra layer)
+~ package app/usecase: Application layer level 1 -> Application layer
++ app/service -> app/usecase
+- app/usecase -> app/service
+`, buf.String())
+
+	assert.True(t, DiffRules(newConfig, newConfig).IsEmpty())
+}
+
+func TestDiffRules_MoveBetweenLayers(t *testing.T) {
+	oldConfig, err := NewParser().ParseDependencyContent(strings.NewReader(ruleDiffNew))
+	require.NoError(t, err)
+	moved := strings.Replace(ruleDiffNew, "  - domain/service\n", "", 1)
+	moved = strings.Replace(moved, "  - api\n", "  - api\n  - domain/service\n", 1)
+	newConfig, err := NewParser().ParseDependencyContent(strings.NewReader(moved))
+	require.NoError(t, err)
+
+	diff := DiffRules(oldConfig, newConfig)
+
+	assert.Equal(t, []PackageMove{
+		{Path: "domain/service", From: &Placement{Layer: "Domain layer"}, To: &Placement{Layer: "Presentation layer"}},
+	}, diff.MovedPackages)
+	assert.Equal(t, []Permission{
+		{Importer: "domain/service", Imported: "app/usecase"},
+		{Importer: "domain/service", Imported: "app/service"},
+		{Importer: "domain/service", Imported: "api"},
+	}, diff.Gained)
+	assert.Equal(t, []Permission{
+		{Importer: "app/usecase", Imported: "domain/service"},
+		{Importer: "app/service", Imported: "domain/service"},
+		{Importer: "api", Imported: "domain/service"},
+	}, diff.Lost)
+}
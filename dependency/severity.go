@@ -0,0 +1,123 @@
+package dependency
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Severity tells how serious a finding is
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// severities lists every severity from the most to the least serious
+var severities = []Severity{SeverityError, SeverityWarning, SeverityInfo}
+
+func (s Severity) String() string { return string(s) }
+
+func (s Severity) IsValid() bool {
+	return s.Validate() == nil
+}
+
+func (s Severity) Validate() error {
+	if !slices.Contains(severities, s) {
+		return fmt.Errorf("unknown severity %q, want error, warning or info", string(s))
+	}
+	return nil
+}
+
+// AtLeast reports whether s is as serious as threshold or more
+func (s Severity) AtLeast(threshold Severity) bool {
+	rank := slices.Index(severities, s)
+	return rank >= 0 && rank <= slices.Index(severities, threshold)
+}
+
+// DefaultSeverity returns the severity of findings of a rule when
+// DEPENDENCY.md does not set one
+func DefaultSeverity(ruleID string) Severity {
+	for _, rule := range rules {
+		if rule.id == ruleID {
+			return rule.severity
+		}
+	}
+	return SeverityError
+}
+
+// validateSeverityRule checks that DEPENDENCY.md may set the severity of a rule
+func validateSeverityRule(ruleID string) error {
+	var ids []string
+	for _, rule := range rules {
+		if rule.id == ruleID {
+			if !rule.configurable {
+				return fmt.Errorf("the severity of %s cannot be changed", ruleID)
+			}
+			return nil
+		}
+		if rule.configurable {
+			ids = append(ids, rule.id)
+		}
+	}
+
+	message := fmt.Sprintf("unknown rule %q", ruleID)
+	if suggestion := closestPath(ruleID, ids); suggestion != "" {
+		message += fmt.Sprintf(" (did you mean %s?)", suggestion)
+	}
+	return errors.New(message)
+}
+
+// Severity returns the severity of findings of a rule, as set in the
+// Severities section or the rule's default
+func (dc *DependencyConfig) Severity(ruleID string) Severity {
+	if dc != nil {
+		if severity, ok := dc.Severities[ruleID]; ok {
+			return severity
+		}
+	}
+	return DefaultSeverity(ruleID)
+}
+
+// tolerate returns err, a problem found by the rule, when the rule's
+// findings are errors. Below that, err is kept in Warnings and parsing
+// goes on without the line or check that failed.
+func (dc *DependencyConfig) tolerate(ruleID string, err error) error {
+	if dc.Severity(ruleID) == SeverityError {
+		return err
+	}
+	dc.Warnings = append(dc.Warnings, err)
+	return nil
+}
+
+// ViolationSeverity returns the severity of a violation: the one its layer,
+// scope or rule sets, or else the severity of its rule
+func (dc *DependencyConfig) ViolationSeverity(violation ImportViolation) Severity {
+	if violation.Severity != "" {
+		return violation.Severity
+	}
+	return dc.Severity(violation.RuleID())
+}
+
+// ApplySeverities sets the severity of every finding from the Severities
+// section, except findings whose layer, scope or rule sets their own
+func (dc *DependencyConfig) ApplySeverities(findings []Finding) []Finding {
+	for i := range findings {
+		if !findings[i].pinned {
+			findings[i].Severity = dc.Severity(findings[i].RuleID)
+		}
+	}
+	return findings
+}
+
+// Failing reports whether any finding is at least as serious as threshold
+func Failing(findings []Finding, threshold Severity) bool {
+	for _, finding := range findings {
+		if finding.Severity.AtLeast(threshold) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,237 @@
+package dependency
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeverity_AtLeast(t *testing.T) {
+	assert.True(t, SeverityError.AtLeast(SeverityError))
+	assert.True(t, SeverityError.AtLeast(SeverityInfo))
+	assert.True(t, SeverityWarning.AtLeast(SeverityWarning))
+	assert.False(t, SeverityWarning.AtLeast(SeverityError))
+	assert.False(t, SeverityInfo.AtLeast(SeverityWarning))
+	assert.False(t, Severity("fatal").AtLeast(SeverityInfo))
+}
+
+func TestParser_ParseSeveritiesSection(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]Severity
+		err      string
+	}{
+		{
+			name: "rules",
+			content: `## Severities
+
+Findings of these rules do not fail the run.
+
+- external-import: warning
+- unused-suppression: info
+`,
+			expected: map[string]Severity{RuleExternalImport: SeverityWarning, RuleUnusedSuppression: SeverityInfo},
+		},
+		{
+			name:    "unknown rule",
+			content: "## Severities\n- external-imports: warning\n",
+			err:     `line 2: unknown rule "external-imports" (did you mean external-import?)`,
+		},
+		{
+			name:    "fixed rule",
+			content: "## Severities\n- error: warning\n",
+			err:     "line 2: the severity of error cannot be changed",
+		},
+		{
+			name:    "unknown severity",
+			content: "## Severities\n- banned-symbol: fatal\n",
+			err:     `line 2: unknown severity "fatal", want error, warning or info`,
+		},
+		{
+			name:    "missing severity",
+			content: "## Severities\n- banned-symbol\n",
+			err:     `line 2: invalid severity "- banned-symbol": want "- rule-id: severity"`,
+		},
+		{
+			name:    "duplicate",
+			content: "## Severities\n- banned-symbol: info\n- banned-symbol: warning\n",
+			err:     "line 3: the severity of banned-symbol is set more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewParser().ParseDependencyContent(strings.NewReader(tt.content))
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, config.Severities)
+		})
+	}
+}
+
+func TestDependencyConfig_ApplySeverities(t *testing.T) {
+	config := &DependencyConfig{Severities: map[string]Severity{RuleBannedSymbol: SeverityWarning}}
+
+	findings := config.ApplySeverities([]Finding{
+		{RuleID: RuleBannedSymbol, Severity: SeverityError},
+		{RuleID: RuleLayerViolation, Severity: SeverityError},
+		{RuleID: RuleBaselineFixed, Severity: SeverityInfo},
+	})
+
+	assert.Equal(t, []Severity{SeverityWarning, SeverityError, SeverityInfo},
+		[]Severity{findings[0].Severity, findings[1].Severity, findings[2].Severity})
+	assert.True(t, Failing(findings, SeverityError))
+	assert.False(t, Failing(findings[:1], SeverityError))
+	assert.True(t, Failing(findings[:1], SeverityWarning))
+}
+
+func TestGenerator_Run_MissingPackageWarning(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity/entity.go"), "package entity\n")
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain"), Order: 1, Packages: []Package{{Path: LayerPath("domian/entity")}, {Path: LayerPath("domain/entity")}}},
+		},
+		Severities: map[string]Severity{RuleMissingPackage: SeverityWarning},
+	}
+
+	generator := NewGenerator()
+	generator.ModuleName = ModuleName("example.com/project")
+	generator.Strict = true
+
+	result, err := generator.Run(context.Background(), tmpDir, config)
+	require.NoError(t, err)
+	assert.Equal(t, []error{MissingPackageError{Path: "domian/entity", Suggestion: "domain/entity"}}, result.Warnings)
+	// The typo only warns, and no directory is created for it
+	assert.Equal(t, []string{"domain/entity/dependency.gen.go"}, result.Created)
+	assert.NoDirExists(t, filepath.Join(tmpDir, "domian"))
+}
+
+func TestAnalyzeImports_SectionSeverities(t *testing.T) {
+	config, err := NewParser().ParseDependencyContent(strings.NewReader(`## Layers
+
+1. Domain layer
+2. Infra layer (warning)
+
+## Packages in layers
+
+1. Domain layer
+  - domain
+2. Infra layer
+  - infra
+  - infra/db
+
+## External imports
+
+1. Domain layer (info)
+  - forbid net/http
+  - forbid github.com/lib/pq (error)
+
+## Banned symbols
+
+- domain
+  - time.Now (warning)
+  - os.Getenv
+
+## Severities
+
+- layer-violation: info
+- banned-symbol: info
+`))
+	require.NoError(t, err)
+	assert.Equal(t, SeverityWarning, config.Layers[1].Severity)
+	assert.Equal(t, LayerName("Infra layer"), config.Layers[1].Name)
+	assert.Equal(t, []Severity{SeverityInfo, SeverityError}, []Severity{config.ExternalImports[0].Severity, config.ExternalImports[1].Severity})
+	assert.Equal(t, ImportPattern("github.com/lib/pq"), config.ExternalImports[1].Pattern)
+	assert.Equal(t, []Severity{SeverityWarning, ""}, []Severity{config.BannedSymbols[0].Severity, config.BannedSymbols[1].Severity})
+
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), "module example.com/app\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(tmpDir, "domain/domain.go"), `package domain
+
+import (
+	_ "example.com/app/infra"
+	_ "github.com/lib/pq"
+	_ "net/http"
+	"os"
+	"time"
+)
+
+var _, _ = time.Now(), os.Getenv("X")
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "infra/infra.go"), "package infra\n\nimport _ \"example.com/app/infra/db\"\n")
+	writeTestFile(t, filepath.Join(tmpDir, "infra/db/db.go"), "package db\n")
+
+	violations, err := NewAnalyzer().AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+	findings := config.ApplySeverities(ViolationFindings(violations))
+
+	severities := map[string]Severity{}
+	for i, violation := range violations {
+		severities[violation.ImportPath+violation.Symbol] = findings[i].Severity
+		assert.Equal(t, findings[i].Severity, config.ViolationSeverity(violation))
+	}
+	assert.Equal(t, map[string]Severity{
+		"example.com/app/infra":    SeverityInfo,    // Severities section
+		"example.com/app/infra/db": SeverityWarning, // Layer line
+		"github.com/lib/pq":        SeverityError,   // Rule line
+		"net/http":                 SeverityInfo,    // Scope line
+		"timetime.Now":             SeverityWarning, // Symbol line
+		"osos.Getenv":              SeverityInfo,    // Severities section
+	}, severities)
+}
+
+func TestParseDependencyContent_ToleratedProblems(t *testing.T) {
+	content := `## Layers
+
+1. Domain layer
+2. Application layer
+
+## Packages in layers
+
+1. Domain layer
+  - domain/entity
+  - ../outside
+  - domain/service
+2. Application layer
+  - app/usecase
+
+## External imports
+
+- domain/[
+  - forbid net/http
+3. Infra layer
+  - forbid database/sql
+
+## Severities
+
+- parse-error: warning
+- invalid-config: info
+`
+	config, err := NewParser().ParseDependencyContent(strings.NewReader(content))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Package{{Path: "domain/entity"}, {Path: "domain/service"}}, config.Layers[0].Packages)
+	assert.Equal(t, []ExternalImportRule{{Scope: RuleScope{Layer: "Infra layer", Order: 3}, Pattern: "database/sql"}}, config.ExternalImports)
+	require.Len(t, config.Warnings, 4)
+
+	findings := config.ApplySeverities(ErrorFindings(config, "DEPENDENCY.md", GenerateError{Errors: config.Warnings}))
+	assert.Equal(t, []Finding{
+		{RuleID: RuleParseError, Severity: SeverityWarning, Message: "invalid package path: package path ../outside must not contain '..'", File: "DEPENDENCY.md", Line: 10},
+		{RuleID: RuleParseError, Severity: SeverityWarning, Message: `invalid external import scope: package pattern "domain/[" is malformed`, File: "DEPENDENCY.md", Line: 17},
+		{RuleID: RuleParseError, Severity: SeverityWarning, Message: `external import rule "forbid net/http" must follow a layer or package pattern`, File: "DEPENDENCY.md", Line: 18},
+		{RuleID: RuleInvalidConfig, Severity: SeverityInfo, Message: `external import rule refers to undefined layer "3. Infra layer"`, File: "DEPENDENCY.md"},
+	}, findings)
+
+	// Without the Severities section the first problem stops parsing
+	_, err = NewParser().ParseDependencyContent(strings.NewReader(content[:strings.Index(content, "## Severities")]))
+	assert.EqualError(t, err, "line 10: invalid package path: package path ../outside must not contain '..'")
+}
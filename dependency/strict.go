@@ -0,0 +1,155 @@
+package dependency
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// walkModule calls fn for every file and directory in fsys, skipping the
+// directories the go command ignores and nested modules
+func walkModule(fsys fs.FS, fn func(name string, d fs.DirEntry) error) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() && name != "." {
+			base := d.Name()
+			if strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_") || base == "testdata" || base == "vendor" {
+				return fs.SkipDir
+			}
+			if _, err := fs.Stat(fsys, path.Join(name, "go.mod")); err == nil {
+				return fs.SkipDir
+			}
+		}
+
+		return fn(name, d)
+	})
+}
+
+// isSourceFile reports whether name is a Go file other than the generated ones
+func isSourceFile(name string, generatedNames ...string) bool {
+	if !strings.HasSuffix(name, ".go") {
+		return false
+	}
+	for _, generated := range generatedNames {
+		if name == generated {
+			return false
+		}
+	}
+	return true
+}
+
+// findPackageDirs returns the directories in fsys that hold Go files other
+// than the generated ones
+func findPackageDirs(fsys fs.FS, generatedNames ...string) ([]string, error) {
+	seen := map[string]bool{}
+	var dirs []string
+	err := walkModule(fsys, func(name string, d fs.DirEntry) error {
+		if d.IsDir() || !isSourceFile(d.Name(), generatedNames...) {
+			return nil
+		}
+		dir := path.Dir(name)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+// checkPackageDirs returns a MissingPackageError for every listed package
+// whose directory does not exist or holds no Go files of its own
+func checkPackageDirs(fsys fs.FS, packages []Package, generatedNames ...string) ([]error, error) {
+	existing, err := findPackageDirs(fsys, generatedNames...)
+	if err != nil {
+		return nil, err
+	}
+
+	hasGoFiles := make(map[string]bool, len(existing))
+	for _, dir := range existing {
+		hasGoFiles[dir] = true
+	}
+
+	var errs []error
+	for _, pkg := range packages {
+		dir := pkg.Path.String()
+		if hasGoFiles[dir] {
+			continue
+		}
+
+		info, err := fs.Stat(fsys, dir)
+		errs = append(errs, MissingPackageError{
+			Path:       dir,
+			Exists:     err == nil && info.IsDir(),
+			Suggestion: closestPath(dir, existing),
+		})
+	}
+
+	return errs, nil
+}
+
+// withoutMissing returns the packages that checkPackageDirs did not report
+func withoutMissing(packages []Package, missing []error) []Package {
+	skip := make(map[LayerPath]bool, len(missing))
+	for _, err := range missing {
+		var missingErr MissingPackageError
+		if errors.As(err, &missingErr) {
+			skip[LayerPath(missingErr.Path)] = true
+		}
+	}
+
+	var kept []Package
+	for _, pkg := range packages {
+		if !skip[pkg.Path] {
+			kept = append(kept, pkg)
+		}
+	}
+	return kept
+}
+
+// closestPath returns the candidate nearest to target by edit distance, or
+// "" when none is close enough to be a likely typo
+func closestPath(target string, candidates []string) string {
+	limit := max(len(target)/3, 1)
+
+	best := ""
+	bestDistance := limit + 1
+	for _, candidate := range candidates {
+		if candidate == target {
+			continue
+		}
+		distance := editDistance(target, candidate)
+		if distance < bestDistance || distance == bestDistance && candidate < best {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
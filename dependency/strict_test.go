@@ -0,0 +1,109 @@
+package dependency
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"entity", "entity", 0},
+		{"", "entity", 6},
+		{"domian/entity", "domain/entity", 2},
+		{"domain/entty", "domain/entity", 1},
+		{"app/usecases", "app/usecase", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.expected, editDistance(tt.a, tt.b))
+			assert.Equal(t, tt.expected, editDistance(tt.b, tt.a))
+		})
+	}
+}
+
+func TestClosestPath(t *testing.T) {
+	candidates := []string{"domain/entity", "domain/service", "app/usecase"}
+
+	assert.Equal(t, "domain/entity", closestPath("domian/entity", candidates))
+	assert.Equal(t, "app/usecase", closestPath("app/usecases", candidates))
+	assert.Equal(t, "", closestPath("infra/database", candidates))
+	assert.Equal(t, "", closestPath("x", candidates))
+}
+
+func TestCheckPackageDirs(t *testing.T) {
+	fsys := fstest.MapFS{
+		"go.mod":                          &fstest.MapFile{Data: []byte("module example.com/app\n")},
+		"domain/entity/user.go":           &fstest.MapFile{Data: []byte("package entity\n")},
+		"domain/entity/dependency.gen.go": &fstest.MapFile{Data: []byte(generatedHeader + "\n\npackage entity\n")},
+		"domain/service/dependency.gen.go": &fstest.MapFile{
+			Data: []byte(generatedHeader + "\n\npackage service\n"),
+		},
+		"app/usecase/README.md":        &fstest.MapFile{Data: []byte("# usecase\n")},
+		"testdata/domain/model/m.go":   &fstest.MapFile{Data: []byte("package model\n")},
+		"nested/domain/model/model.go": &fstest.MapFile{Data: []byte("package model\n")},
+		"nested/go.mod":                &fstest.MapFile{Data: []byte("module example.com/nested\n")},
+	}
+
+	packages := []Package{
+		{Path: LayerPath("domain/entity")},
+		{Path: LayerPath("domian/entity")},
+		{Path: LayerPath("domain/service")},
+		{Path: LayerPath("app/usecase")},
+		{Path: LayerPath("domain/model")},
+	}
+
+	errs, err := checkPackageDirs(fsys, packages, GeneratedFileName, GeneratedTestFileName)
+	require.NoError(t, err)
+	assert.Equal(t, []error{
+		MissingPackageError{Path: "domian/entity", Suggestion: "domain/entity"},
+		MissingPackageError{Path: "domain/service", Exists: true},
+		MissingPackageError{Path: "app/usecase", Exists: true},
+		MissingPackageError{Path: "domain/model"},
+	}, errs)
+}
+
+func TestMissingPackageError(t *testing.T) {
+	assert.Equal(t, "package directory domian/entity does not exist (did you mean domain/entity?)",
+		MissingPackageError{Path: "domian/entity", Suggestion: "domain/entity"}.Error())
+	assert.Equal(t, "package directory app/usecase has no Go files",
+		MissingPackageError{Path: "app/usecase", Exists: true}.Error())
+}
+
+func TestGenerator_Run_Strict(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domian/entity")}}},
+		},
+	}
+
+	output := NewMemoryOutput()
+	output["domain/entity/user.go"] = []byte("package entity\n")
+
+	generator := NewGenerator()
+	generator.Output = output
+	generator.ModuleName = ModuleName("github.com/test/project")
+	generator.Strict = true
+
+	_, err := generator.Run(context.Background(), ".", config)
+
+	var missingErr MissingPackageError
+	require.ErrorAs(t, err, &missingErr)
+	assert.Equal(t, "domain/entity", missingErr.Suggestion)
+	assert.Equal(t, []string{"domain/entity/user.go"}, output.Names())
+
+	// Without strict mode the directory is scaffolded
+	generator.Strict = false
+	_, err = generator.Run(context.Background(), ".", config)
+	require.NoError(t, err)
+	assert.Contains(t, output.Names(), "domian/entity/dependency.gen.go")
+}
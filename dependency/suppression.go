@@ -0,0 +1,199 @@
+package dependency
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// suppressionDirective starts a comment that suppresses violations, like
+//
+//	//depcheck:ignore reason="legacy import, see #123" until=2027-01-01
+//
+// on the line of an import spec or just above it, or before the package
+// clause to suppress every violation in the file
+const suppressionDirective = "//depcheck:ignore"
+
+// suppressionDateLayout is the format of the until date
+const suppressionDateLayout = "2006-01-02"
+
+// SuppressionProblem tells why a //depcheck:ignore comment is reported
+type SuppressionProblem int
+
+const (
+	SuppressionNone    SuppressionProblem = iota
+	SuppressionInvalid                    // No reason, a malformed until date or an unknown argument
+	SuppressionExpired                    // The until date has passed
+	SuppressionUnused                     // Nothing to suppress
+)
+
+// suppression is a parsed //depcheck:ignore comment
+type suppression struct {
+	pos        token.Pos
+	importPath string // Empty for a file-level suppression
+	reason     string
+	until      time.Time // Zero when it never expires
+	invalid    string    // Why the comment is invalid, empty when valid
+	used       bool
+}
+
+// parseSuppression parses the text of a comment, and reports whether it is
+// a //depcheck:ignore directive at all
+func parseSuppression(text string) (suppression, bool) {
+	rest, ok := strings.CutPrefix(text, suppressionDirective)
+	if !ok || rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return suppression{}, false
+	}
+
+	var (
+		s        suppression
+		reason   []string
+		inReason bool
+	)
+	for _, field := range strings.Fields(rest) {
+		switch {
+		case strings.HasPrefix(field, "reason="):
+			reason = []string{strings.TrimPrefix(field, "reason=")}
+			inReason = true
+		case strings.HasPrefix(field, "until="):
+			inReason = false
+			value := strings.TrimPrefix(field, "until=")
+			until, err := time.Parse(suppressionDateLayout, value)
+			if err != nil {
+				s.invalid = fmt.Sprintf("until date %q is not YYYY-MM-DD", value)
+				continue
+			}
+			s.until = until
+		case inReason:
+			reason = append(reason, field)
+		default:
+			if s.invalid == "" {
+				s.invalid = fmt.Sprintf("unexpected argument %q", field)
+			}
+		}
+	}
+
+	s.reason = strings.Join(reason, " ")
+	if unquoted, err := strconv.Unquote(s.reason); err == nil {
+		s.reason = unquoted
+	}
+	if strings.TrimSpace(s.reason) == "" && s.invalid == "" {
+		s.invalid = "reason=... is required"
+	}
+
+	return s, true
+}
+
+// findSuppressions returns the //depcheck:ignore comments before the
+// package clause and those attached to import specs
+func findSuppressions(file *ast.File) []*suppression {
+	var suppressions []*suppression
+	add := func(group *ast.CommentGroup, importPath string) {
+		if group == nil {
+			return
+		}
+		for _, comment := range group.List {
+			if s, ok := parseSuppression(comment.Text); ok {
+				s.pos = comment.Pos()
+				s.importPath = importPath
+				suppressions = append(suppressions, &s)
+			}
+		}
+	}
+
+	for _, group := range file.Comments {
+		if group.Pos() < file.Package {
+			add(group, "")
+		}
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			spec := spec.(*ast.ImportSpec)
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			// A lone import without parentheses takes the comment above the declaration
+			if !gen.Lparen.IsValid() {
+				add(gen.Doc, importPath)
+			}
+			add(spec.Doc, importPath)
+			add(spec.Comment, importPath)
+		}
+	}
+
+	return suppressions
+}
+
+// applySuppressions drops the violations that a valid suppression covers,
+// and adds a violation for every suppression that is invalid, expired or unused
+func applySuppressions(fset *token.FileSet, file *ast.File, filePath string, pkg Package, kind FileKind, violations []ImportViolation, now time.Time) []ImportViolation {
+	suppressions := findSuppressions(file)
+	if len(suppressions) == 0 {
+		return violations
+	}
+
+	var active []*suppression
+	for _, s := range suppressions {
+		if s.invalid == "" && (s.until.IsZero() || now.Before(s.until)) {
+			active = append(active, s)
+		}
+	}
+
+	var kept []ImportViolation
+	for _, violation := range violations {
+		suppressed := false
+		for _, s := range active {
+			if s.importPath == "" || s.importPath == violation.ImportPath {
+				s.used = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			kept = append(kept, violation)
+		}
+	}
+
+	for _, s := range suppressions {
+		var (
+			problem SuppressionProblem
+			reason  string
+		)
+		switch {
+		case s.invalid != "":
+			problem = SuppressionInvalid
+			reason = fmt.Sprintf("invalid %s: %s", suppressionDirective, s.invalid)
+		case !s.until.IsZero() && !now.Before(s.until):
+			problem = SuppressionExpired
+			reason = fmt.Sprintf("%s expired on %s (%s)", suppressionDirective, s.until.Format(suppressionDateLayout), s.reason)
+		case !s.used:
+			problem = SuppressionUnused
+			reason = fmt.Sprintf("%s suppresses nothing (%s)", suppressionDirective, s.reason)
+		default:
+			continue
+		}
+
+		position := fset.Position(s.pos)
+		kept = append(kept, ImportViolation{
+			File:        filePath,
+			Line:        position.Line,
+			Column:      position.Column,
+			Importer:    pkg.Path,
+			ImportPath:  s.importPath,
+			Reason:      reason,
+			Suppression: problem,
+			FileKind:    kind,
+			Pos:         s.pos,
+		})
+	}
+
+	return kept
+}
@@ -0,0 +1,114 @@
+package dependency
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSuppression(t *testing.T) {
+	tests := []struct {
+		text    string
+		ok      bool
+		reason  string
+		until   string
+		invalid string
+	}{
+		{text: "//depcheck:ignore reason=legacy", ok: true, reason: "legacy"},
+		{text: "//depcheck:ignore reason=moved in the next release until=2027-01-01", ok: true, reason: "moved in the next release", until: "2027-01-01"},
+		{text: `//depcheck:ignore until=2027-01-01 reason="see #123"`, ok: true, reason: "see #123", until: "2027-01-01"},
+		{text: "//depcheck:ignore", ok: true, invalid: "reason=... is required"},
+		{text: "//depcheck:ignore reason=", ok: true, invalid: "reason=... is required"},
+		{text: "//depcheck:ignore reason=x until=01/01/2027", ok: true, reason: "x", invalid: `until date "01/01/2027" is not YYYY-MM-DD`},
+		{text: "//depcheck:ignore because reason=x", ok: true, reason: "x", invalid: `unexpected argument "because"`},
+		{text: "//depcheck:ignored reason=x"},
+		{text: "// depcheck:ignore reason=x"},
+		{text: "// regular comment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			s, ok := parseSuppression(tt.text)
+			require.Equal(t, tt.ok, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.reason, s.reason)
+			assert.Equal(t, tt.invalid, s.invalid)
+			if tt.until != "" {
+				assert.Equal(t, tt.until, s.until.Format(suppressionDateLayout))
+			}
+		})
+	}
+}
+
+func TestAnalyzeImports_Suppressions(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), "module github.com/test/project\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(tmpDir, "infra/infra.go"), "package infra\n")
+	writeTestFile(t, filepath.Join(tmpDir, "domain/entity/entity.go"), `package entity
+
+import (
+	//depcheck:ignore reason="legacy repository, see #123"
+	_ "github.com/test/project/infra"
+	_ "net/http" //depcheck:ignore reason=moving to a port until=2026-01-01
+	"time" //depcheck:ignore reason="clock injected in #140"
+	_ "fmt" //depcheck:ignore reason=not needed
+	_ "os" //depcheck:ignore until=2030-01-01
+)
+
+var _ = time.Now()
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "domain/service/service.go"), `//depcheck:ignore reason="whole file is being rewritten"
+
+package service
+
+import (
+	_ "github.com/test/project/infra"
+	_ "net/http"
+)
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "domain/valueobject/valueobject.go"), `package valueobject
+
+//depcheck:ignore reason=single import
+import _ "github.com/test/project/infra"
+`)
+
+	domain := RuleScope{Layer: LayerName("Domain layer"), Order: 1}
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{
+				{Path: LayerPath("domain/entity")},
+				{Path: LayerPath("domain/service")},
+				{Path: LayerPath("domain/valueobject")},
+			}},
+			{Name: LayerName("Infrastructure layer"), Order: 2, Packages: []Package{{Path: LayerPath("infra")}}},
+		},
+		ExternalImports: []ExternalImportRule{
+			{Scope: domain, Pattern: ImportPattern("net/http")},
+		},
+		BannedSymbols: []BannedSymbolRule{
+			{Scope: domain, Symbol: BannedSymbol{ImportPath: "time", Name: "Now"}},
+		},
+	}
+
+	analyzer := NewAnalyzer()
+	analyzer.Now = time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	violations, err := analyzer.AnalyzeImports(tmpDir, config)
+	require.NoError(t, err)
+
+	var found []string
+	for _, violation := range violations {
+		found = append(found, violation.RuleID()+" "+violation.String()[len(tmpDir)+1:])
+	}
+	assert.Equal(t, []string{
+		"external-import domain/entity/entity.go:6:2: domain/entity must not import net/http (1. Domain layer: forbid net/http)",
+		"expired-suppression domain/entity/entity.go:6:15: //depcheck:ignore expired on 2026-01-01 (moving to a port)",
+		"unused-suppression domain/entity/entity.go:8:10: //depcheck:ignore suppresses nothing (not needed)",
+		"invalid-suppression domain/entity/entity.go:9:9: invalid //depcheck:ignore: reason=... is required",
+	}, found)
+}
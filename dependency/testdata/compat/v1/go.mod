@@ -0,0 +1,3 @@
+module github.com/example/compat
+
+go 1.21
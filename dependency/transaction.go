@@ -0,0 +1,157 @@
+package dependency
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// change is a staged write or removal together with the content it replaces
+type change struct {
+	name string
+	// content is nil when the file is removed
+	content []byte
+	// previous is nil when the file did not exist
+	previous []byte
+}
+
+// transaction applies staged changes to an output and can undo them.
+// Undoing needs an ExistingOutput; other outputs are written without rollback.
+// apply may be called from several goroutines. Only OSOutput is called
+// concurrently; calls to other outputs are serialized.
+type transaction struct {
+	output     Output
+	existing   fs.FS
+	baseDir    string
+	concurrent bool
+
+	outputMu sync.Mutex
+	mu       sync.Mutex
+
+	applied     []change
+	createdDirs []string
+}
+
+func newTransaction(output Output, baseDir string) *transaction {
+	tx := &transaction{output: output, baseDir: baseDir}
+	if existingOutput, ok := output.(ExistingOutput); ok {
+		tx.existing = existingOutput.FS()
+	}
+	_, tx.concurrent = output.(*OSOutput)
+	return tx
+}
+
+// call runs fn, which uses the output, serialized unless the output is
+// safe for concurrent use
+func (tx *transaction) call(fn func() error) error {
+	if !tx.concurrent {
+		tx.outputMu.Lock()
+		defer tx.outputMu.Unlock()
+	}
+	return fn()
+}
+
+func (tx *transaction) path(name string) string {
+	return filepath.Join(tx.baseDir, filepath.FromSlash(name))
+}
+
+// apply writes or removes one file and remembers how to undo it
+func (tx *transaction) apply(c change) error {
+	if c.content == nil {
+		if err := tx.call(func() error { return tx.output.(ExistingOutput).Remove(c.name) }); err != nil {
+			return FileRemoveError{Path: tx.path(c.name), Err: err}
+		}
+		tx.record(c)
+		return nil
+	}
+
+	if err := tx.mkdirAll(path.Dir(c.name)); err != nil {
+		return err
+	}
+	if err := tx.call(func() error { return tx.output.WriteFile(c.name, c.content) }); err != nil {
+		return FileWriteError{Path: tx.path(c.name), Err: err}
+	}
+	tx.record(c)
+	return nil
+}
+
+func (tx *transaction) record(c change) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.applied = append(tx.applied, c)
+}
+
+// mkdirAll creates dir and records which of its parents did not exist before
+func (tx *transaction) mkdirAll(dir string) error {
+	var missing []string
+	if tx.existing != nil {
+		for d := dir; d != "." && d != "/"; d = path.Dir(d) {
+			if _, err := fs.Stat(tx.existing, d); err == nil {
+				break
+			}
+			missing = append([]string{d}, missing...)
+		}
+	}
+
+	// Recorded before creating, since MkdirAll may fail halfway
+	tx.mu.Lock()
+	for _, d := range missing {
+		if !slices.Contains(tx.createdDirs, d) {
+			tx.createdDirs = append(tx.createdDirs, d)
+		}
+	}
+	tx.mu.Unlock()
+
+	if err := tx.call(func() error { return tx.output.MkdirAll(dir) }); err != nil {
+		return DirectoryCreationError{Path: tx.path(dir), Err: err}
+	}
+	return nil
+}
+
+// rollback restores the previous files and removes the directories the
+// transaction created, returning every failure. It must not run
+// concurrently with apply.
+func (tx *transaction) rollback() []error {
+	existingOutput, ok := tx.output.(ExistingOutput)
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	for i := len(tx.applied) - 1; i >= 0; i-- {
+		c := tx.applied[i]
+		if c.previous == nil {
+			if err := existingOutput.Remove(c.name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, FileRemoveError{Path: tx.path(c.name), Err: err})
+			}
+			continue
+		}
+
+		if err := existingOutput.MkdirAll(path.Dir(c.name)); err != nil {
+			errs = append(errs, DirectoryCreationError{Path: tx.path(path.Dir(c.name)), Err: err})
+			continue
+		}
+		if err := existingOutput.WriteFile(c.name, c.previous); err != nil {
+			errs = append(errs, FileWriteError{Path: tx.path(c.name), Err: err})
+		}
+	}
+
+	// Remove the deepest directories first, since concurrent applies may
+	// have recorded them in any order
+	slices.SortStableFunc(tx.createdDirs, func(a, b string) int {
+		return strings.Count(b, "/") - strings.Count(a, "/")
+	})
+	for _, dir := range tx.createdDirs {
+		if err := existingOutput.Remove(dir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, FileRemoveError{Path: tx.path(dir), Err: err})
+		}
+	}
+
+	tx.applied = nil
+	tx.createdDirs = nil
+	return errs
+}
@@ -0,0 +1,122 @@
+package dependency
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingOutput is a MemoryOutput that fails to write one file
+type failingOutput struct {
+	MemoryOutput
+	failName string
+}
+
+func (o failingOutput) WriteFile(name string, data []byte) error {
+	if name == o.failName {
+		return errors.New("disk full")
+	}
+	return o.MemoryOutput.WriteFile(name, data)
+}
+
+func TestGenerator_Run_RollsBackOnWriteFailure(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Application layer"), Order: 2, Packages: []Package{
+				{Path: LayerPath("app/usecase")},
+				{Path: LayerPath("app/service")},
+			}},
+		},
+	}
+
+	output := failingOutput{MemoryOutput: NewMemoryOutput(), failName: "app/service/dependency.gen.go"}
+	output.MemoryOutput["app/usecase/dependency.gen.go"] = []byte(generatedHeader + "\n\npackage usecase\n")
+	output.MemoryOutput["infra/old/dependency.gen.go"] = []byte(generatedHeader + "\n\npackage old\n")
+
+	generator := NewGenerator()
+	generator.Output = output
+	generator.ModuleName = ModuleName("github.com/test/project")
+
+	result, err := generator.Run(context.Background(), "project", config)
+	assert.Nil(t, result)
+
+	var generateErr GenerateError
+	require.ErrorAs(t, err, &generateErr)
+	require.Len(t, generateErr.Errors, 1)
+
+	var writeErr FileWriteError
+	require.ErrorAs(t, err, &writeErr)
+	assert.Equal(t, filepath.Join("project", "app/service/dependency.gen.go"), writeErr.Path)
+
+	// The output is back to where it started
+	assert.Equal(t, []string{"app/usecase/dependency.gen.go", "infra/old/dependency.gen.go"}, output.Names())
+	assert.Equal(t, generatedHeader+"\n\npackage usecase\n", string(output.MemoryOutput["app/usecase/dependency.gen.go"]))
+}
+
+func TestGenerator_Run_RollsBackOnDirectoryCreationFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "go.mod"), []byte("module github.com/test/project\n"), 0644))
+
+	// A file where a package directory should be makes MkdirAll fail
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "infra"), []byte("not a directory\n"), 0644))
+
+	existingPath := filepath.Join(tmpDir, "app", "usecase", GeneratedFileName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(existingPath), 0755))
+	require.NoError(t, os.WriteFile(existingPath, []byte(generatedHeader+"\n\npackage usecase\n"), 0644))
+
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+			{Name: LayerName("Application layer"), Order: 2, Packages: []Package{{Path: LayerPath("app/usecase")}}},
+			{Name: LayerName("Infra layer"), Order: 3, Packages: []Package{{Path: LayerPath("infra/db")}}},
+		},
+	}
+
+	generator := NewGenerator()
+	_, err := generator.Run(context.Background(), tmpDir, config)
+
+	var dirErr DirectoryCreationError
+	require.ErrorAs(t, err, &dirErr)
+	assert.Equal(t, filepath.Join(tmpDir, "infra", "db"), dirErr.Path)
+
+	// Created directories are removed and the existing file is restored
+	assert.NoDirExists(t, filepath.Join(tmpDir, "domain"))
+	content, err := os.ReadFile(existingPath)
+	require.NoError(t, err)
+	assert.Equal(t, generatedHeader+"\n\npackage usecase\n", string(content))
+}
+
+func TestGenerator_Run_ReportsEveryFormatError(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{
+				{Path: LayerPath("domain/entity")},
+				{Path: LayerPath("domain/value-object")},
+				{Path: LayerPath("domain/read-model")},
+			}},
+		},
+	}
+
+	output := NewMemoryOutput()
+	generator := NewGenerator()
+	generator.Output = output
+	generator.ModuleName = ModuleName("github.com/test/project")
+
+	_, err := generator.Run(context.Background(), ".", config)
+
+	var generateErr GenerateError
+	require.ErrorAs(t, err, &generateErr)
+	require.Len(t, generateErr.Errors, 2)
+	assert.Contains(t, err.Error(), "2 errors")
+	assert.Contains(t, err.Error(), "value-object")
+	assert.Contains(t, err.Error(), "read-model")
+
+	// Nothing is written when any file fails to format
+	assert.Empty(t, output)
+}
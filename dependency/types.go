@@ -0,0 +1,653 @@
+package dependency
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Custom types for better type safety
+type LayerName string
+type LayerPath string
+type ModuleName string
+type PackageName string
+type FilePath string
+
+func (ln LayerName) String() string   { return string(ln) }
+func (lp LayerPath) String() string   { return string(lp) }
+func (mn ModuleName) String() string  { return string(mn) }
+func (pn PackageName) String() string { return string(pn) }
+func (fp FilePath) String() string    { return string(fp) }
+
+// Validation methods for custom types
+func (ln LayerName) IsValid() bool {
+	return ln != "" && strings.TrimSpace(string(ln)) != ""
+}
+
+func (ln LayerName) Validate() error {
+	if !ln.IsValid() {
+		return fmt.Errorf("layer name cannot be empty")
+	}
+	return nil
+}
+
+func (lp LayerPath) IsValid() bool {
+	return lp.Validate() == nil
+}
+
+func (lp LayerPath) Validate() error {
+	_, err := lp.Normalize()
+	return err
+}
+
+// Normalize returns the clean, relative, slash-separated form of the path,
+// e.g. ./domain/entity/ becomes domain/entity. Every element must be valid
+// in a Go import path, so the path can never leave the base directory.
+func (lp LayerPath) Normalize() (LayerPath, error) {
+	raw := strings.TrimSpace(string(lp))
+	if raw == "" {
+		return "", fmt.Errorf("layer path cannot be empty")
+	}
+
+	if strings.HasPrefix(raw, "/") || strings.HasPrefix(raw, `\`) || hasDriveLetter(raw) {
+		return "", AbsolutePathError{Path: raw}
+	}
+
+	for _, element := range strings.FieldsFunc(raw, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if element == ".." {
+			return "", PathTraversalError{Path: raw}
+		}
+	}
+
+	clean := path.Clean(raw)
+	for _, element := range strings.Split(clean, "/") {
+		if !isValidPathElement(element) {
+			return "", InvalidPathElementError{Path: raw, Element: element}
+		}
+	}
+
+	return LayerPath(clean), nil
+}
+
+// hasDriveLetter reports whether p starts with a Windows drive such as C:
+func hasDriveLetter(p string) bool {
+	return len(p) >= 2 && p[1] == ':' && ('a' <= p[0] && p[0] <= 'z' || 'A' <= p[0] && p[0] <= 'Z')
+}
+
+// isValidPathElement follows the rules for import path elements: ASCII
+// letters, digits and -._~+, not starting or ending with a dot
+func isValidPathElement(element string) bool {
+	if element == "" || strings.HasPrefix(element, ".") || strings.HasSuffix(element, ".") {
+		return false
+	}
+	for _, r := range element {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case strings.ContainsRune("-._~+", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (mn ModuleName) IsValid() bool {
+	return mn != "" && strings.TrimSpace(string(mn)) != "" && !strings.Contains(string(mn), " ")
+}
+
+func (mn ModuleName) Validate() error {
+	if mn == "" || strings.TrimSpace(string(mn)) == "" {
+		return fmt.Errorf("module name cannot be empty")
+	}
+	if strings.Contains(string(mn), " ") {
+		return fmt.Errorf("module name cannot contain spaces")
+	}
+	return nil
+}
+
+// RelativePath returns the import path relative to the module, reporting
+// whether the import path belongs to the module at all
+func (mn ModuleName) RelativePath(importPath string) (LayerPath, bool) {
+	if !strings.HasPrefix(importPath, string(mn)+"/") {
+		return "", false
+	}
+	return LayerPath(strings.TrimPrefix(importPath, string(mn)+"/")), true
+}
+
+func (pn PackageName) IsValid() bool {
+	return pn != "" && strings.TrimSpace(string(pn)) != "" && !strings.Contains(string(pn), "/")
+}
+
+func (pn PackageName) Validate() error {
+	if pn == "" || strings.TrimSpace(string(pn)) == "" {
+		return fmt.Errorf("package name cannot be empty")
+	}
+	if strings.Contains(string(pn), "/") {
+		return fmt.Errorf("package name cannot contain '/'")
+	}
+	return nil
+}
+
+// Custom error types for better error handling
+type DirectoryCreationError struct {
+	Path string
+	Err  error
+}
+
+func (e DirectoryCreationError) Error() string {
+	return fmt.Sprintf("failed to create directory %s: %v", e.Path, e.Err)
+}
+
+func (e DirectoryCreationError) Unwrap() error {
+	return e.Err
+}
+
+type FileWriteError struct {
+	Path string
+	Err  error
+}
+
+func (e FileWriteError) Error() string {
+	return fmt.Sprintf("failed to write %s: %v", e.Path, e.Err)
+}
+
+func (e FileWriteError) Unwrap() error {
+	return e.Err
+}
+
+type FileFormatError struct {
+	Path string
+	Err  error
+}
+
+func (e FileFormatError) Error() string {
+	return fmt.Sprintf("failed to format %s: %v", e.Path, e.Err)
+}
+
+func (e FileFormatError) Unwrap() error {
+	return e.Err
+}
+
+type FileRemoveError struct {
+	Path string
+	Err  error
+}
+
+func (e FileRemoveError) Error() string {
+	return fmt.Sprintf("failed to remove %s: %v", e.Path, e.Err)
+}
+
+func (e FileRemoveError) Unwrap() error {
+	return e.Err
+}
+
+// GenerateError lists every failure of a generation run, including
+// failures to roll back files that had already been written
+type GenerateError struct {
+	Errors []error
+}
+
+func (e GenerateError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	if len(messages) == 1 {
+		return messages[0]
+	}
+	return fmt.Sprintf("%d errors: %s", len(messages), strings.Join(messages, "; "))
+}
+
+func (e GenerateError) Unwrap() []error {
+	return e.Errors
+}
+
+// ParseError is returned for a line of DEPENDENCY.md that cannot be parsed
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned when DEPENDENCY.md parses but its rules
+// contradict each other
+type ValidationError struct {
+	Err error
+}
+
+func (e ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// AbsolutePathError is returned for a package path that is not relative
+// to the base directory
+type AbsolutePathError struct {
+	Path string
+}
+
+func (e AbsolutePathError) Error() string {
+	return fmt.Sprintf("package path %s must be relative to the module root", e.Path)
+}
+
+// PathTraversalError is returned for a package path with a .. element
+type PathTraversalError struct {
+	Path string
+}
+
+func (e PathTraversalError) Error() string {
+	return fmt.Sprintf("package path %s must not contain '..'", e.Path)
+}
+
+// InvalidPathElementError is returned for a package path with an element
+// that is not valid in a Go import path, such as one with a backslash
+type InvalidPathElementError struct {
+	Path    string
+	Element string
+}
+
+func (e InvalidPathElementError) Error() string {
+	return fmt.Sprintf("package path %s has invalid element %q", e.Path, e.Element)
+}
+
+// PathEscapeError is returned instead of writing through a symlink that
+// resolves outside the base directory
+type PathEscapeError struct {
+	Path     string
+	Resolved string
+}
+
+func (e PathEscapeError) Error() string {
+	return fmt.Sprintf("refusing to write %s: it resolves to %s outside the module root", e.Path, e.Resolved)
+}
+
+// ForeignFileError is returned instead of overwriting a file that was not
+// generated by go-package-dependency
+type ForeignFileError struct {
+	Path string
+}
+
+func (e ForeignFileError) Error() string {
+	return fmt.Sprintf("refusing to overwrite %s: it has no generated code header", e.Path)
+}
+
+// MissingPackageError is returned in strict mode for a listed package whose
+// directory does not exist or holds no Go files
+type MissingPackageError struct {
+	Path string
+	// Exists is true when the directory exists but holds no Go files
+	Exists bool
+	// Suggestion is the closest existing package directory, if any
+	Suggestion string
+}
+
+func (e MissingPackageError) Error() string {
+	message := fmt.Sprintf("package directory %s does not exist", e.Path)
+	if e.Exists {
+		message = fmt.Sprintf("package directory %s has no Go files", e.Path)
+	}
+	if e.Suggestion != "" {
+		message += fmt.Sprintf(" (did you mean %s?)", e.Suggestion)
+	}
+	return message
+}
+
+type InvalidFileNameError struct {
+	Name   string
+	Reason string
+}
+
+func (e InvalidFileNameError) Error() string {
+	return fmt.Sprintf("invalid output file name %q: %s", e.Name, e.Reason)
+}
+
+type PackageNotFoundError struct {
+	Path string
+}
+
+func (e PackageNotFoundError) Error() string {
+	return fmt.Sprintf("package %s is not listed", e.Path)
+}
+
+type SourceParseError struct {
+	Path string
+	Err  error
+}
+
+func (e SourceParseError) Error() string {
+	return fmt.Sprintf("failed to parse %s: %v", e.Path, e.Err)
+}
+
+func (e SourceParseError) Unwrap() error {
+	return e.Err
+}
+
+type UnknownStyleError struct {
+	Style string
+}
+
+func (e UnknownStyleError) Error() string {
+	return fmt.Sprintf("unknown style: %s", e.Style)
+}
+
+type ModuleNotFoundError struct {
+	Source string
+}
+
+func (e ModuleNotFoundError) Error() string {
+	return fmt.Sprintf("module declaration not found in %s", e.Source)
+}
+
+// Package represents a single package with its hierarchical level
+type Package struct {
+	Path  LayerPath // e.g., "domain/entity", "domain/service"
+	Level int       // Indentation level (0 = top level, 1 = one indent, etc.)
+}
+
+// Layer represents a layer with its packages
+type Layer struct {
+	Name     LayerName
+	Order    int       // Layer order (1, 2, 3, ...)
+	Isolated bool      // Packages in this layer cannot depend on each other
+	Packages []Package // Packages in this layer
+	Severity Severity  // Severity of layer violations by its packages, empty for the rule's
+}
+
+// DependencyConfig represents the complete dependency configuration
+type DependencyConfig struct {
+	Style  Style     // Architecture preset, empty for plain numbered layers
+	Shared []Package // Packages every layer may import, above all layers
+	Layers []Layer
+
+	ExternalImports []ExternalImportRule // Rules for imports from outside the module
+	BannedSymbols   []BannedSymbolRule   // Symbols that packages must not use
+
+	TestMode    TestMode         // Rules for _test.go files, empty means production
+	TestImports []TestImportRule // Extra imports allowed in tests for the custom test mode
+
+	Severities map[string]Severity // Severity per rule ID, from the Severities section
+
+	// Warnings are the parse errors and validation errors of DEPENDENCY.md
+	// that were skipped because the Severities section lowers their rule
+	Warnings []error
+
+	index *packageIndex     // Only set on snapshots made by indexed
+	lines map[LayerPath]int // Line each package is listed on, set by the parser
+}
+
+// PackageLine returns the line of DEPENDENCY.md that lists the package,
+// or 0 when the config was not parsed from a file
+func (dc *DependencyConfig) PackageLine(path LayerPath) int {
+	return dc.lines[path]
+}
+
+// recordLine remembers the line of the last package in packages unless
+// the package was listed before
+func (dc *DependencyConfig) recordLine(packages []Package, line int) {
+	if len(packages) == 0 {
+		return
+	}
+	path := packages[len(packages)-1].Path
+	if _, ok := dc.lines[path]; ok {
+		return
+	}
+	if dc.lines == nil {
+		dc.lines = map[LayerPath]int{}
+	}
+	dc.lines[path] = line
+}
+
+// GetAllPackages returns all packages across all layers, shared packages first
+func (dc *DependencyConfig) GetAllPackages() []Package {
+	var allPackages []Package
+	allPackages = append(allPackages, dc.Shared...)
+	for _, layer := range dc.Layers {
+		allPackages = append(allPackages, layer.Packages...)
+	}
+	return allPackages
+}
+
+// normalized returns a copy of the config with every package path
+// normalized the way the parser does, so configs built in code may use
+// paths like ./domain/entity. The config itself is left untouched.
+func (dc *DependencyConfig) normalized() (*DependencyConfig, []error) {
+	var invalid []error
+	normalize := func(packages []Package) []Package {
+		copied := make([]Package, len(packages))
+		for i, pkg := range packages {
+			path, err := pkg.Path.Normalize()
+			if err != nil {
+				invalid = append(invalid, err)
+				path = pkg.Path
+			}
+			pkg.Path = path
+			copied[i] = pkg
+		}
+		return copied
+	}
+
+	copied := *dc
+	copied.index = nil
+	copied.Shared = normalize(dc.Shared)
+	copied.Layers = make([]Layer, len(dc.Layers))
+	for i, layer := range dc.Layers {
+		layer.Packages = normalize(layer.Packages)
+		copied.Layers[i] = layer
+	}
+	return &copied, invalid
+}
+
+// GetPackagesByLayer returns packages for a specific layer
+func (dc *DependencyConfig) GetPackagesByLayer(layerName LayerName) []Package {
+	for _, layer := range dc.Layers {
+		if layer.Name == layerName {
+			return layer.Packages
+		}
+	}
+	return nil
+}
+
+// FindLayer returns the layer that lists the package, or nil
+func (dc *DependencyConfig) FindLayer(packagePath LayerPath) *Layer {
+	if index := dc.index; index != nil {
+		position, ok := index.layered[packagePath]
+		if !ok {
+			return nil
+		}
+		return &dc.Layers[position.layer]
+	}
+
+	for i := range dc.Layers {
+		for _, pkg := range dc.Layers[i].Packages {
+			if pkg.Path == packagePath {
+				return &dc.Layers[i]
+			}
+		}
+	}
+	return nil
+}
+
+// IsShared reports whether the path is listed in the shared section
+func (dc *DependencyConfig) IsShared(path LayerPath) bool {
+	if index := dc.index; index != nil {
+		_, ok := index.shared[path]
+		return ok
+	}
+
+	for _, pkg := range dc.Shared {
+		if pkg.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateShared checks that shared packages are listed once and in no layer
+func (dc *DependencyConfig) ValidateShared() error {
+	seen := make(map[LayerPath]bool)
+	for _, pkg := range dc.Shared {
+		if seen[pkg.Path] {
+			return fmt.Errorf("shared package %s is listed more than once", pkg.Path)
+		}
+		seen[pkg.Path] = true
+	}
+
+	for _, layer := range dc.Layers {
+		for _, pkg := range layer.Packages {
+			if seen[pkg.Path] {
+				return fmt.Errorf("package %s cannot be both shared and in %q", pkg.Path, layer.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// GetDependenciesForPackage calculates dependencies for a given package
+func (dc *DependencyConfig) GetDependenciesForPackage(targetPackage Package) []LayerPath {
+	if index := dc.index; index != nil {
+		return dc.indexedDependencies(index, targetPackage)
+	}
+
+	var dependencies []LayerPath
+
+	// Shared packages may only depend on shared packages above them
+	if dc.IsShared(targetPackage.Path) {
+		return getSiblingDependencies(dc.Shared, targetPackage)
+	}
+
+	// Find the layer containing this package
+	targetLayer := dc.FindLayer(targetPackage.Path)
+	if targetLayer == nil {
+		return dependencies
+	}
+
+	// Every layer may depend on shared packages
+	for _, pkg := range dc.Shared {
+		dependencies = append(dependencies, pkg.Path)
+	}
+
+	// Add dependencies from upper layers (layers with lower order)
+	for _, layer := range dc.Layers {
+		if layer.Order < targetLayer.Order {
+			for _, pkg := range layer.Packages {
+				dependencies = append(dependencies, pkg.Path)
+			}
+		}
+	}
+
+	// Packages in an isolated layer cannot depend on each other
+	if targetLayer.Isolated {
+		return dependencies
+	}
+
+	// Add dependencies from the same layer
+	dependencies = append(dependencies, getSiblingDependencies(targetLayer.Packages, targetPackage)...)
+
+	return dependencies
+}
+
+// indexedDependencies is GetDependenciesForPackage using the index
+func (dc *DependencyConfig) indexedDependencies(index *packageIndex, targetPackage Package) []LayerPath {
+	if position, ok := index.shared[targetPackage.Path]; ok {
+		return siblingDependencies(dc.Shared, targetPackage, position)
+	}
+
+	position, ok := index.layered[targetPackage.Path]
+	if !ok {
+		return nil
+	}
+
+	// Copied, since callers may sort or append to the result
+	dependencies := append([]LayerPath(nil), index.upper[position.layer]...)
+
+	targetLayer := dc.Layers[position.layer]
+	if targetLayer.Isolated {
+		return dependencies
+	}
+	return append(dependencies, siblingDependencies(targetLayer.Packages, targetPackage, position.position)...)
+}
+
+// getSiblingDependencies returns the packages in the same list that the target may depend on
+func getSiblingDependencies(packages []Package, targetPackage Package) []LayerPath {
+	// Find target package index
+	var targetIndex int
+	for i, pkg := range packages {
+		if pkg.Path == targetPackage.Path {
+			targetIndex = i
+			break
+		}
+	}
+
+	return siblingDependencies(packages, targetPackage, targetIndex)
+}
+
+func siblingDependencies(packages []Package, targetPackage Package, targetIndex int) []LayerPath {
+	var dependencies []LayerPath
+
+	for i, pkg := range packages {
+		if pkg.Path != targetPackage.Path {
+			// Can depend on packages at higher levels (lower level number)
+			// or packages at the same level that come before in the hierarchy
+			if isSiblingDependency(pkg.Level, i, targetPackage.Level, targetIndex) {
+				dependencies = append(dependencies, pkg.Path)
+			}
+		}
+	}
+
+	return dependencies
+}
+
+// FindPackage returns the listed package that contains the given path,
+// either the package itself or its closest listed ancestor
+func (dc *DependencyConfig) FindPackage(path LayerPath) (Package, bool) {
+	if index := dc.index; index != nil {
+		return index.findPackage(dc, path)
+	}
+
+	var found Package
+	matched := false
+	for _, pkg := range dc.GetAllPackages() {
+		if path != pkg.Path && !strings.HasPrefix(string(path), string(pkg.Path)+"/") {
+			continue
+		}
+		if !matched || len(pkg.Path) > len(found.Path) {
+			found = pkg
+			matched = true
+		}
+	}
+	return found, matched
+}
+
+// IsDependencyAllowed reports whether the importer package may import the imported package
+func (dc *DependencyConfig) IsDependencyAllowed(importer Package, imported Package) bool {
+	if index := dc.index; index != nil {
+		return index.isDependencyAllowed(dc, importer, imported)
+	}
+
+	if importer.Path == imported.Path {
+		return true
+	}
+	for _, dep := range dc.GetDependenciesForPackage(importer) {
+		if dep == imported.Path {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPackageName extracts the package name from a package path
+func GetPackageName(packagePath LayerPath) PackageName {
+	path := string(packagePath)
+	parts := strings.Split(path, "/")
+	return PackageName(parts[len(parts)-1])
+}
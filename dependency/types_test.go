@@ -1,10 +1,11 @@
-package main
+package dependency
 
 import (
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Test String methods for custom types
@@ -38,6 +39,46 @@ func TestFilePath_String(t *testing.T) {
 	assert.Equal(t, expected, fp.String())
 }
 
+func TestLayerPath_Normalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    LayerPath
+		expected LayerPath
+		err      error
+	}{
+		{"clean path", LayerPath("domain/entity"), LayerPath("domain/entity"), nil},
+		{"leading dot slash", LayerPath("./domain/entity"), LayerPath("domain/entity"), nil},
+		{"trailing slash", LayerPath("domain/entity/"), LayerPath("domain/entity"), nil},
+		{"repeated slashes", LayerPath("domain//entity"), LayerPath("domain/entity"), nil},
+		{"inner dot", LayerPath("domain/./entity"), LayerPath("domain/entity"), nil},
+		{"surrounding spaces", LayerPath("  domain/entity "), LayerPath("domain/entity"), nil},
+		{"allowed punctuation", LayerPath("infra/my-db_v2.x~1+a"), LayerPath("infra/my-db_v2.x~1+a"), nil},
+		{"absolute path", LayerPath("/etc/x"), "", AbsolutePathError{Path: "/etc/x"}},
+		{"absolute backslash path", LayerPath(`\\server\share`), "", AbsolutePathError{Path: `\\server\share`}},
+		{"drive letter", LayerPath(`C:\Windows`), "", AbsolutePathError{Path: `C:\Windows`}},
+		{"parent directory", LayerPath("../outside"), "", PathTraversalError{Path: "../outside"}},
+		{"inner parent directory", LayerPath("domain/../../outside"), "", PathTraversalError{Path: "domain/../../outside"}},
+		{"backslash parent directory", LayerPath(`domain\..\..\outside`), "", PathTraversalError{Path: `domain\..\..\outside`}},
+		{"backslash separator", LayerPath(`domain\entity`), "", InvalidPathElementError{Path: `domain\entity`, Element: `domain\entity`}},
+		{"module root", LayerPath("."), "", InvalidPathElementError{Path: ".", Element: "."}},
+		{"hidden directory", LayerPath("domain/.git"), "", InvalidPathElementError{Path: "domain/.git", Element: ".git"}},
+		{"space in element", LayerPath("domain/my entity"), "", InvalidPathElementError{Path: "domain/my entity", Element: "my entity"}},
+		{"non-ASCII element", LayerPath("domain/エンティティ"), "", InvalidPathElementError{Path: "domain/エンティティ", Element: "エンティティ"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.input.Normalize()
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 // Test LayerName validation
 func TestLayerName_IsValid(t *testing.T) {
 	tests := []struct {
@@ -95,8 +136,12 @@ func TestLayerPath_IsValid(t *testing.T) {
 		{"whitespace only", LayerPath("   "), false},
 		{"path with parent directory", LayerPath("path/../other"), false},
 		{"path with double dots", LayerPath("path/.."), false},
-		{"single dot", LayerPath("."), true},
-		{"root path", LayerPath("/"), true},
+		{"single dot", LayerPath("."), false},
+		{"root path", LayerPath("/"), false},
+		{"leading dot slash", LayerPath("./path/to/layer"), true},
+		{"trailing slash", LayerPath("path/to/layer/"), true},
+		{"absolute path", LayerPath("/etc/x"), false},
+		{"backslash", LayerPath(`path\layer`), false},
 	}
 
 	for _, tt := range tests {
@@ -227,12 +272,6 @@ func TestPackageName_Validate(t *testing.T) {
 }
 
 // Test custom error types
-func TestUnsupportedReaderError(t *testing.T) {
-	err := UnsupportedReaderError{ReaderType: "unknown"}
-	expected := "unsupported reader type: unknown"
-	assert.Equal(t, expected, err.Error())
-}
-
 func TestDirectoryCreationError(t *testing.T) {
 	innerErr := errors.New("permission denied")
 	err := DirectoryCreationError{Path: "/tmp/test", Err: innerErr}
@@ -254,6 +293,19 @@ func TestFileFormatError(t *testing.T) {
 	assert.Equal(t, expected, err.Error())
 }
 
+func TestSourceParseError(t *testing.T) {
+	innerErr := errors.New("expected 'package'")
+	err := SourceParseError{Path: "/tmp/code.go", Err: innerErr}
+	expected := "failed to parse /tmp/code.go: expected 'package'"
+	assert.Equal(t, expected, err.Error())
+}
+
+func TestUnknownStyleError(t *testing.T) {
+	err := UnknownStyleError{Style: "onion"}
+	expected := "unknown style: onion"
+	assert.Equal(t, expected, err.Error())
+}
+
 func TestModuleNotFoundError(t *testing.T) {
 	err := ModuleNotFoundError{Source: "go.mod"}
 	expected := "module declaration not found in go.mod"
@@ -450,6 +502,194 @@ func TestDependencyConfig_GetDependenciesForPackage(t *testing.T) {
 	}
 }
 
+// Test GetDependenciesForPackage with isolated layers
+func TestDependencyConfig_GetDependenciesForPackage_Isolated(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{
+				Name:  LayerName("Domain"),
+				Order: 1,
+				Packages: []Package{
+					{Path: LayerPath("domain"), Level: 0},
+				},
+			},
+			{
+				Name:     LayerName("Ports"),
+				Order:    2,
+				Isolated: true,
+				Packages: []Package{
+					{Path: LayerPath("ports/in"), Level: 0},
+					{Path: LayerPath("ports/out"), Level: 0},
+				},
+			},
+			{
+				Name:     LayerName("Adapters"),
+				Order:    3,
+				Isolated: true,
+				Packages: []Package{
+					{Path: LayerPath("adapters/http"), Level: 0},
+					{Path: LayerPath("adapters/postgres"), Level: 0},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		targetPackage Package
+		expectedDeps  []LayerPath
+	}{
+		{
+			name:          "ports only use domain",
+			targetPackage: Package{Path: LayerPath("ports/out"), Level: 0},
+			expectedDeps:  []LayerPath{LayerPath("domain")},
+		},
+		{
+			name:          "adapters use ports and domain but not each other",
+			targetPackage: Package{Path: LayerPath("adapters/postgres"), Level: 0},
+			expectedDeps:  []LayerPath{LayerPath("domain"), LayerPath("ports/in"), LayerPath("ports/out")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ElementsMatch(t, tt.expectedDeps, config.GetDependenciesForPackage(tt.targetPackage))
+		})
+	}
+}
+
+// Test GetDependenciesForPackage with shared packages
+func TestDependencyConfig_GetDependenciesForPackage_Shared(t *testing.T) {
+	config := &DependencyConfig{
+		Shared: []Package{
+			{Path: LayerPath("pkg/errors"), Level: 0},
+			{Path: LayerPath("pkg/logging"), Level: 1},
+		},
+		Layers: []Layer{
+			{
+				Name:  LayerName("Domain layer"),
+				Order: 1,
+				Packages: []Package{
+					{Path: LayerPath("domain/entity"), Level: 0},
+				},
+			},
+			{
+				Name:  LayerName("Application layer"),
+				Order: 2,
+				Packages: []Package{
+					{Path: LayerPath("app/usecase"), Level: 0},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		targetPackage Package
+		expectedDeps  []LayerPath
+	}{
+		{
+			name:          "top shared package has no dependencies",
+			targetPackage: Package{Path: LayerPath("pkg/errors"), Level: 0},
+			expectedDeps:  nil,
+		},
+		{
+			name:          "shared package depends on shared packages above it",
+			targetPackage: Package{Path: LayerPath("pkg/logging"), Level: 1},
+			expectedDeps:  []LayerPath{LayerPath("pkg/errors")},
+		},
+		{
+			name:          "domain depends on shared packages",
+			targetPackage: Package{Path: LayerPath("domain/entity"), Level: 0},
+			expectedDeps:  []LayerPath{LayerPath("pkg/errors"), LayerPath("pkg/logging")},
+		},
+		{
+			name:          "application depends on shared packages and domain",
+			targetPackage: Package{Path: LayerPath("app/usecase"), Level: 0},
+			expectedDeps:  []LayerPath{LayerPath("pkg/errors"), LayerPath("pkg/logging"), LayerPath("domain/entity")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ElementsMatch(t, tt.expectedDeps, config.GetDependenciesForPackage(tt.targetPackage))
+		})
+	}
+
+	assert.True(t, config.IsShared(LayerPath("pkg/logging")))
+	assert.False(t, config.IsShared(LayerPath("domain/entity")))
+	assert.Len(t, config.GetAllPackages(), 4)
+}
+
+// Test ValidateShared method
+func TestDependencyConfig_ValidateShared(t *testing.T) {
+	config := &DependencyConfig{
+		Shared: []Package{{Path: LayerPath("pkg/errors")}},
+		Layers: []Layer{
+			{Name: LayerName("Domain layer"), Order: 1, Packages: []Package{{Path: LayerPath("domain/entity")}}},
+		},
+	}
+	assert.NoError(t, config.ValidateShared())
+
+	config.Shared = append(config.Shared, Package{Path: LayerPath("pkg/errors")})
+	assert.Error(t, config.ValidateShared())
+
+	config.Shared = []Package{{Path: LayerPath("domain/entity")}}
+	assert.Error(t, config.ValidateShared())
+}
+
+// Test FindPackage method
+func TestDependencyConfig_FindPackage(t *testing.T) {
+	config := &DependencyConfig{
+		Layers: []Layer{
+			{
+				Name:  LayerName("Domain layer"),
+				Order: 1,
+				Packages: []Package{
+					{Path: LayerPath("domain"), Level: 0},
+					{Path: LayerPath("domain/entity"), Level: 0},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		path     LayerPath
+		expected LayerPath
+		found    bool
+	}{
+		{"exact match", LayerPath("domain/entity"), LayerPath("domain/entity"), true},
+		{"closest ancestor", LayerPath("domain/entity/user"), LayerPath("domain/entity"), true},
+		{"outer ancestor", LayerPath("domain/service"), LayerPath("domain"), true},
+		{"shared prefix is not an ancestor", LayerPath("domainx"), LayerPath(""), false},
+		{"unlisted", LayerPath("app/usecase"), LayerPath(""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, found := config.FindPackage(tt.path)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.expected, pkg.Path)
+		})
+	}
+}
+
+// Test ModuleName.RelativePath method
+func TestModuleName_RelativePath(t *testing.T) {
+	mn := ModuleName("github.com/example/project")
+
+	path, ok := mn.RelativePath("github.com/example/project/domain/entity")
+	assert.True(t, ok)
+	assert.Equal(t, LayerPath("domain/entity"), path)
+
+	_, ok = mn.RelativePath("github.com/example/projectx/domain")
+	assert.False(t, ok)
+
+	_, ok = mn.RelativePath("fmt")
+	assert.False(t, ok)
+}
+
 // Test GetPackageName function
 func TestGetPackageName(t *testing.T) {
 	tests := []struct {
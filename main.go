@@ -1,38 +1,582 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/handlename/go-package-dependency/dependency"
 )
 
 func main() {
 	var (
-		app                = kingpin.New("go-package-dependency", "Generate dependency.gen.go files based on DEPENDENCY.md")
-		dependencyFilePath = app.Arg("dependency-file", "Path to the DEPENDENCY.md file").Required().String()
+		app     = kingpin.New("go-package-dependency", "Generate dependency.gen.go files based on DEPENDENCY.md")
+		workers = app.Flag("workers", "Number of files to process at once, 0 for one per CPU").Default("0").Int()
+		format  = app.Flag("format", "Output format: text, json, sarif, junit, checkstyle, github or gitlab").Default(textFormat).Enum(formats()...)
+		failOn  = app.Flag("fail-on", "Lowest severity of findings that fails the run: error, warning, info or none").Default(string(dependency.SeverityError)).Enum("error", "warning", "info", failOnNone)
+
+		generateCommand            = app.Command("generate", "Generate dependency.gen.go files").Default()
+		generateDependencyFilePath = generateCommand.Arg("dependency-file", "Path to the DEPENDENCY.md file, or - to read from stdin").Required().String()
+		generateTestFiles          = generateCommand.Flag("test-files", "Also generate dependency_gen_test.go files for external test packages").Bool()
+		generateForce              = generateCommand.Flag("force", "Overwrite existing files that were not generated by go-package-dependency").Bool()
+		generateOutputName         = generateCommand.Flag("output-name", "Name of the generated file in each package").Default(dependency.GeneratedFileName).String()
+		generateStrict             = generateCommand.Flag("strict", "Fail when a listed package directory does not exist or has no Go files").Bool()
+
+		checkCommand            = app.Command("check", "Report generated files that are out of date with DEPENDENCY.md")
+		checkDependencyFilePath = checkCommand.Arg("dependency-file", "Path to the DEPENDENCY.md file, or - to read from stdin").Required().String()
+		checkTestFiles          = checkCommand.Flag("test-files", "Also check dependency_gen_test.go files for external test packages").Bool()
+		checkOutputName         = checkCommand.Flag("output-name", "Name of the generated file in each package").Default(dependency.GeneratedFileName).String()
+		checkScaffold           = checkCommand.Flag("scaffold", "Allow listed package directories that do not exist yet").Bool()
+
+		analyzeCommand            = app.Command("analyze", "Report imports that DEPENDENCY.md does not allow")
+		analyzeDependencyFilePath = analyzeCommand.Arg("dependency-file", "Path to the DEPENDENCY.md file, or - to read from stdin").Required().String()
+		analyzeBaseline           = analyzeCommand.Flag("baseline", "Path to the baseline of known violations, used when it exists").Default("").String()
+		analyzeWriteBaseline      = analyzeCommand.Flag("write-baseline", "Record the current violations to the baseline instead of reporting them").Bool()
+
+		diffRulesCommand = app.Command("diff-rules", "Show what a change to DEPENDENCY.md allows and forbids")
+		diffRulesOld     = diffRulesCommand.Arg("old", "Path to the old DEPENDENCY.md, or git:<ref>:<path> to read it from git").Required().String()
+		diffRulesNew     = diffRulesCommand.Arg("new", "Path to the new DEPENDENCY.md, or git:<ref>:<path> to read it from git").Required().String()
+
+		metricsCommand            = app.Command("metrics", "Report coupling, instability and abstractness of packages and layers")
+		metricsDependencyFilePath = metricsCommand.Arg("dependency-file", "Path to the DEPENDENCY.md file, or - to read from stdin").Required().String()
+
+		reportCommand            = app.Command("report", "Write an HTML page with the layers, allowed dependencies, violations and metrics")
+		reportDependencyFilePath = reportCommand.Arg("dependency-file", "Path to the DEPENDENCY.md file, or - to read from stdin").Required().String()
+		reportHTML               = reportCommand.Flag("html", "Path of the HTML file to write").Required().String()
+
+		matrixCommand            = app.Command("matrix", "Print the package by package matrix of allowed dependencies")
+		matrixDependencyFilePath = matrixCommand.Arg("dependency-file", "Path to the DEPENDENCY.md file, or - to read from stdin").Required().String()
+		matrixImports            = matrixCommand.Flag("imports", "Also read the imports of the packages to mark the ones in use and the violations").Bool()
+		matrixCSV                = matrixCommand.Flag("csv", "Print CSV instead of a Markdown table").Bool()
 	)
 
 	app.HelpFlag.Short('h')
 	app.Version(Version)
 
-	kingpin.MustParse(app.Parse(os.Args[1:]))
+	command := kingpin.MustParse(app.Parse(os.Args[1:]))
+	r := reporter{format: *format, failOn: *failOn}
+
+	switch command {
+	case generateCommand.FullCommand():
+		runGenerate(dependencyFileArg(*generateDependencyFilePath), r, dependency.GenerateOptions{
+			TestFiles: *generateTestFiles,
+			Force:     *generateForce,
+			FileName:  *generateOutputName,
+			Strict:    *generateStrict,
+			Workers:   *workers,
+		})
+	case checkCommand.FullCommand():
+		runCheck(dependencyFileArg(*checkDependencyFilePath), r, dependency.GenerateOptions{
+			TestFiles: *checkTestFiles,
+			FileName:  *checkOutputName,
+			Strict:    !*checkScaffold,
+			Workers:   *workers,
+		})
+	case analyzeCommand.FullCommand():
+		runAnalyze(dependencyFileArg(*analyzeDependencyFilePath), r, analyzeOptions{
+			workers:       *workers,
+			baseline:      *analyzeBaseline,
+			writeBaseline: *analyzeWriteBaseline,
+		})
+	case diffRulesCommand.FullCommand():
+		runDiffRules(*diffRulesOld, *diffRulesNew, r)
+	case metricsCommand.FullCommand():
+		runMetrics(dependencyFileArg(*metricsDependencyFilePath), r, *workers)
+	case reportCommand.FullCommand():
+		runReport(dependencyFileArg(*reportDependencyFilePath), *reportHTML, r, *workers)
+	case matrixCommand.FullCommand():
+		runMatrix(dependencyFileArg(*matrixDependencyFilePath), r, matrixOptions{
+			workers: *workers,
+			imports: *matrixImports,
+			csv:     *matrixCSV,
+		})
+	}
+}
+
+func runGenerate(dependencyFilePath string, r reporter, opts dependency.GenerateOptions) {
+	opts.BaseDir = baseDir(dependencyFilePath)
+	opts.Config = parseDependencyFile(dependencyFilePath, r)
 
-	parser := NewParser()
-	config, err := parser.ParseDependencyFile(*dependencyFilePath)
+	// Broken diagram markers fail the run before any file is written
+	diagram, err := diagramDiff(dependencyFilePath, opts.Config)
 	if err != nil {
-		fmt.Printf("Error parsing dependency file: %v\n", err)
+		if !r.text() {
+			r.report(dependency.ErrorFindings(opts.Config, reportFile(dependencyFilePath), err), err)
+		}
+		fmt.Printf("Error updating the diagram: %v\n", err)
 		os.Exit(1)
 	}
 
-	baseDir := filepath.Dir(*dependencyFilePath)
-	generator := NewGenerator()
-	err = generator.GenerateDependencyFiles(baseDir, config)
+	// DEPENDENCY.md is updated before the generated files and restored when
+	// generation fails, so that both change or neither does
+	if diagram != nil {
+		if err := dependency.ReplaceFile(dependencyFilePath, diagram.New); err != nil {
+			if !r.text() {
+				r.report(dependency.ErrorFindings(opts.Config, reportFile(dependencyFilePath), err), err)
+			}
+			fmt.Printf("Error updating the diagram: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	result, err := dependency.GenerateFiles(context.Background(), opts)
 	if err != nil {
+		if diagram != nil {
+			if restoreErr := dependency.ReplaceFile(dependencyFilePath, diagram.Old); restoreErr != nil {
+				err = errors.Join(err, fmt.Errorf("restoring the diagram: %w", restoreErr))
+			}
+		}
+		if !r.text() {
+			r.report(dependency.ErrorFindings(opts.Config, reportFile(dependencyFilePath), err), err)
+		}
 		fmt.Printf("Error generating dependency files: %v\n", err)
+		if errors.As(err, &dependency.ForeignFileError{}) {
+			fmt.Println("Use --force to overwrite files that were not generated, or --output-name to pick another name")
+		}
+		os.Exit(1)
+	}
+
+	warnings := append(configFindings(dependencyFilePath, opts.Config),
+		opts.Config.ApplySeverities(dependency.ErrorFindings(opts.Config, reportFile(dependencyFilePath), dependency.GenerateError{Errors: result.Warnings}))...)
+	if !r.text() {
+		r.report(warnings, nil)
+		return
+	}
+
+	printFindings(os.Stdout, warnings)
+
+	fmt.Printf("Generated %s files successfully: %s\n", opts.FileName, result)
+	if diagram != nil {
+		fmt.Printf("Updated the diagram in %s\n", dependencyFilePath)
+	}
+	r.exit(warnings)
+}
+
+func runCheck(dependencyFilePath string, r reporter, opts dependency.GenerateOptions) {
+	opts.BaseDir = baseDir(dependencyFilePath)
+	opts.Config = parseDependencyFile(dependencyFilePath, r)
+
+	// Generate in memory and compare with the files on disk
+	output := dependency.NewDiffOutput(dependency.NewMemoryOutput(), os.DirFS(opts.BaseDir))
+	opts.Output = output
+
+	result, err := dependency.GenerateFiles(context.Background(), opts)
+	if err != nil {
+		if !r.text() {
+			r.report(dependency.ErrorFindings(opts.Config, reportFile(dependencyFilePath), err), err)
+		}
+		fmt.Printf("Error checking dependency files: %v\n", err)
+		if errors.As(err, &dependency.MissingPackageError{}) {
+			fmt.Println("Use --scaffold to allow package directories that do not exist yet")
+		}
+		os.Exit(1)
+	}
+
+	diagram, err := diagramDiff(dependencyFilePath, opts.Config)
+	if err != nil {
+		if !r.text() {
+			r.report(dependency.ErrorFindings(opts.Config, reportFile(dependencyFilePath), err), err)
+		}
+		fmt.Printf("Error checking the diagram: %v\n", err)
+		os.Exit(1)
+	}
+
+	warnings := dependency.ErrorFindings(opts.Config, reportFile(dependencyFilePath), dependency.GenerateError{Errors: slices.Concat(opts.Config.Warnings, result.Warnings)})
+	diffs := output.Diffs()
+	if diagram != nil {
+		diffs = append(diffs, *diagram)
+	}
+	findings := opts.Config.ApplySeverities(append(warnings, dependency.DiffFindings(opts.BaseDir, diffs)...))
+	if !r.text() {
+		r.report(findings, nil)
+		return
+	}
+
+	printFindings(os.Stdout, findings[:len(warnings)])
+	if len(output.Diffs()) > 0 {
+		fmt.Print(output.Unified())
+		fmt.Printf("Found %d out-of-date %s files\n", len(output.Diffs()), opts.FileName)
+	} else {
+		fmt.Printf("%s files are up to date\n", opts.FileName)
+	}
+	if diagram != nil {
+		fmt.Print(diagram.Unified())
+		fmt.Printf("The diagram in %s is out of date; run generate to update it\n", dependencyFilePath)
+	}
+	r.exit(findings)
+}
+
+// diagramDiff returns the change that regenerating the diagram makes to
+// DEPENDENCY.md, or nil when it is up to date, has no diagram markers or
+// was read from stdin
+func diagramDiff(dependencyFilePath string, config *dependency.Config) (*dependency.FileDiff, error) {
+	if dependencyFilePath == stdinPath {
+		return nil, nil
+	}
+	content, err := os.ReadFile(dependencyFilePath)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := dependency.UpdateDiagram(content, config)
+	if err != nil || bytes.Equal(content, updated) {
+		return nil, err
+	}
+	return &dependency.FileDiff{Name: filepath.Base(dependencyFilePath), Old: content, New: updated}, nil
+}
+
+type analyzeOptions struct {
+	workers int
+	// baseline is the path of the baseline file, BaselineFileName next to
+	// DEPENDENCY.md when empty
+	baseline      string
+	writeBaseline bool
+}
+
+func runAnalyze(dependencyFilePath string, r reporter, opts analyzeOptions) {
+	config := parseDependencyFile(dependencyFilePath, r)
+	base := baseDir(dependencyFilePath)
+	if opts.baseline == "" {
+		opts.baseline = filepath.Join(base, dependency.BaselineFileName)
+	}
+
+	analyzer := dependency.NewAnalyzer()
+	analyzer.Workers = opts.workers
+	violations, err := analyzer.AnalyzeImports(base, config)
+
+	if err == nil && opts.writeBaseline {
+		err = dependency.NewBaseline(base, violations).WriteFile(opts.baseline)
+		if err == nil && r.text() {
+			fmt.Printf("Recorded %d import violations in %s\n", len(violations), opts.baseline)
+		}
+		violations = nil
+	}
+
+	var fixed []dependency.BaselineEntry
+	if err == nil && !opts.writeBaseline {
+		var baseline *dependency.Baseline
+		baseline, err = dependency.ReadBaselineFile(opts.baseline)
+		if baseline != nil {
+			violations, fixed = baseline.Filter(base, violations)
+		}
+	}
+
+	if err != nil {
+		if !r.text() {
+			r.report(dependency.ErrorFindings(config, reportFile(dependencyFilePath), err), err)
+		}
+		fmt.Printf("Error analyzing imports: %v\n", err)
+		os.Exit(1)
+	}
+
+	problems := configFindings(dependencyFilePath, config)
+	findings := config.ApplySeverities(dependency.ViolationFindings(violations))
+	fixedFindings := config.ApplySeverities(dependency.BaselineFindings(opts.baseline, fixed))
+	if !r.text() {
+		r.report(slices.Concat(problems, findings, fixedFindings), nil)
+		return
+	}
+	printFindings(os.Stdout, problems)
+	if opts.writeBaseline {
+		r.exit(problems)
+		return
+	}
+
+	for _, entry := range fixed {
+		fmt.Printf("Fixed: %s\n", entry)
+	}
+	if len(fixed) > 0 {
+		fmt.Printf("%d baseline violations are fixed; run with --write-baseline to remove them from %s\n", len(fixed), opts.baseline)
+	}
+
+	for i, violation := range violations {
+		if findings[i].Severity == dependency.SeverityError {
+			fmt.Println(violation)
+			continue
+		}
+		fmt.Printf("%s:%d:%d: %s: %s\n", violation.File, violation.Line, violation.Column, findings[i].Severity, violation.Message())
+	}
+
+	if len(violations) > 0 {
+		fmt.Printf("Found %d import violations\n", len(violations))
+	} else {
+		fmt.Println("No import violations found")
+	}
+	r.exit(append(problems, findings...))
+}
+
+func runDiffRules(oldArg, newArg string, r reporter) {
+	r.requireTextOrJSON("diff-rules")
+
+	oldConfig, err := readRules(oldArg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", oldArg, err)
+		os.Exit(1)
+	}
+	newConfig, err := readRules(newArg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", newArg, err)
+		os.Exit(1)
+	}
+	printFindings(os.Stderr, configFindings(oldArg, oldConfig))
+	printFindings(os.Stderr, configFindings(newArg, newConfig))
+
+	diff := dependency.DiffRules(oldConfig, newConfig)
+	if !r.text() {
+		err = printJSON(diff)
+	} else if diff.IsEmpty() {
+		fmt.Println("No rule changes")
+	} else {
+		err = diff.Write(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing diff: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runMetrics(dependencyFilePath string, r reporter, workers int) {
+	r.requireTextOrJSON("metrics")
+	config := parseDependencyFile(dependencyFilePath, r)
+	printFindings(os.Stderr, configFindings(dependencyFilePath, config))
+
+	analyzer := dependency.NewAnalyzer()
+	analyzer.Workers = workers
+	graph, err := analyzer.BuildImportGraph(context.Background(), baseDir(dependencyFilePath), config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading imports: %v\n", err)
+		os.Exit(1)
+	}
+
+	metrics := dependency.ComputeMetrics(config, graph)
+	if r.text() {
+		err = metrics.Write(os.Stdout)
+	} else {
+		err = printJSON(metrics)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing metrics: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runReport(dependencyFilePath string, htmlPath string, r reporter, workers int) {
+	config := parseDependencyFile(dependencyFilePath, r)
+	printFindings(os.Stderr, configFindings(dependencyFilePath, config))
+	base := baseDir(dependencyFilePath)
+
+	moduleName, err := dependency.NewParser().GetModuleName(filepath.Join(base, "go.mod"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading go.mod: %v\n", err)
+		os.Exit(1)
+	}
+
+	analyzer := dependency.NewAnalyzer()
+	analyzer.Workers = workers
+	violations, err := analyzer.AnalyzeImports(base, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error analyzing imports: %v\n", err)
+		os.Exit(1)
+	}
+	graph, err := analyzer.BuildImportGraph(context.Background(), base, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading imports: %v\n", err)
+		os.Exit(1)
+	}
+
+	findings := config.ApplySeverities(dependency.ViolationFindings(violations))
+	report := dependency.NewHTMLReport(moduleName.String(), config, graph, findings)
+
+	var buf bytes.Buffer
+	if err := report.WriteHTML(&buf); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(htmlPath, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if r.text() {
+		fmt.Printf("Wrote %s\n", htmlPath)
+	}
+}
+
+type matrixOptions struct {
+	workers int
+	imports bool // Read the import graph
+	csv     bool // Print CSV instead of Markdown in the text format
+}
+
+func runMatrix(dependencyFilePath string, r reporter, opts matrixOptions) {
+	r.requireTextOrJSON("matrix")
+	config := parseDependencyFile(dependencyFilePath, r)
+	printFindings(os.Stderr, configFindings(dependencyFilePath, config))
+
+	var graph *dependency.ImportGraph
+	if opts.imports {
+		analyzer := dependency.NewAnalyzer()
+		analyzer.Workers = opts.workers
+		var err error
+		graph, err = analyzer.BuildImportGraph(context.Background(), baseDir(dependencyFilePath), config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading imports: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	matrix := dependency.NewMatrix(config, graph)
+	var err error
+	switch {
+	case !r.text():
+		err = printJSON(matrix)
+	case opts.csv:
+		err = matrix.WriteCSV(os.Stdout)
+	default:
+		err = matrix.WriteMarkdown(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing matrix: %v\n", err)
 		os.Exit(1)
 	}
+}
+
+// printJSON prints v as indented JSON
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// readRules parses DEPENDENCY.md from stdin or a source that
+// dependency.ParseSource reads
+func readRules(arg string) (*dependency.Config, error) {
+	if arg == stdinPath {
+		return dependency.Parse(os.Stdin)
+	}
+	return dependency.ParseSource(arg)
+}
+
+// printFindings prints findings that have no output of their own, like
+// missing packages below the error severity
+func printFindings(w io.Writer, findings []dependency.Finding) {
+	for _, finding := range findings {
+		fmt.Fprintf(w, "%s: %s\n", finding.Severity, finding)
+	}
+}
 
-	fmt.Println("Generated dependency.gen.go files successfully")
+// configFindings returns the problems of DEPENDENCY.md that parsing skipped
+// because the Severities section lowers their rule
+func configFindings(dependencyFilePath string, config *dependency.Config) []dependency.Finding {
+	return config.ApplySeverities(dependency.ErrorFindings(config, reportFile(dependencyFilePath), dependency.GenerateError{Errors: config.Warnings}))
+}
+
+// textFormat is the --format that prints messages for people instead of a report
+const textFormat = "text"
+
+// formats returns the values --format accepts
+func formats() []string {
+	values := []string{textFormat}
+	for _, format := range dependency.Formats {
+		values = append(values, string(format))
+	}
+	return values
+}
+
+// failOnNone is the --fail-on that never fails because of findings
+const failOnNone = "none"
+
+// reporter prints findings in the --format format and fails the run when
+// a finding reaches the --fail-on severity
+type reporter struct {
+	format string
+	failOn string
+}
+
+// text reports whether messages are printed for people
+func (r reporter) text() bool {
+	return r.format == textFormat
+}
+
+// exit exits with 1 when any finding reaches the --fail-on severity
+func (r reporter) exit(findings []dependency.Finding) {
+	if r.failOn != failOnNone && dependency.Failing(findings, dependency.Severity(r.failOn)) {
+		os.Exit(1)
+	}
+}
+
+// requireTextOrJSON exits for commands that print something other than
+// findings, which only have a text and a JSON form
+func (r reporter) requireTextOrJSON(command string) {
+	if !r.text() && r.format != string(dependency.FormatJSON) {
+		fmt.Fprintf(os.Stderr, "Error: %s supports --format text or json, not %s\n", command, r.format)
+		os.Exit(1)
+	}
+}
+
+// report prints findings in a machine-readable format and exits with 1
+// when err is set or a finding reaches the --fail-on severity
+func (r reporter) report(findings []dependency.Finding, err error) {
+	if err := dependency.WriteReport(os.Stdout, dependency.Format(r.format), findings); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+	r.exit(findings)
+}
+
+// reportFile returns the path of DEPENDENCY.md that findings point to,
+// empty when it was read from stdin
+func reportFile(dependencyFilePath string) string {
+	if dependencyFilePath == stdinPath {
+		return ""
+	}
+	return filepath.ToSlash(dependencyFilePath)
+}
+
+// stdinPath is the dependency-file argument that reads DEPENDENCY.md from stdin.
+const stdinPath = "-"
+
+// dependencyFileArg restores stdinPath, which kingpin parses into an empty argument.
+func dependencyFileArg(arg string) string {
+	if arg == "" {
+		return stdinPath
+	}
+	return arg
+}
+
+// baseDir returns the directory that package paths in DEPENDENCY.md are relative to.
+// When reading from stdin, the current directory is used.
+func baseDir(dependencyFilePath string) string {
+	if dependencyFilePath == stdinPath {
+		return "."
+	}
+	return filepath.Dir(dependencyFilePath)
+}
+
+func parseDependencyFile(dependencyFilePath string, r reporter) *dependency.Config {
+	var (
+		config *dependency.Config
+		err    error
+	)
+	if dependencyFilePath == stdinPath {
+		config, err = dependency.Parse(os.Stdin)
+	} else {
+		config, err = dependency.NewParser().ParseDependencyFile(dependencyFilePath)
+	}
+	if err != nil {
+		if !r.text() {
+			r.report(dependency.ErrorFindings(nil, reportFile(dependencyFilePath), err), err)
+		}
+		fmt.Printf("Error parsing dependency file: %v\n", err)
+		os.Exit(1)
+	}
+	return config
 }